@@ -1,8 +1,8 @@
 module proto.zip/studio/mux
 
-go 1.20
+go 1.21
 
 require (
-	golang.org/x/text v0.12.0 // indirect
+	golang.org/x/text v0.12.0
 	proto.zip/studio/validate v0.1.0
 )
@@ -0,0 +1,2 @@
+// Package cache provides a size and time bounded cache for values that are expensive to look up.
+package cache
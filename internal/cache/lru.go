@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// entry is a single cached value and the time it expires.
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // expires is the zero time if the entry never expires.
+}
+
+// LRU is a least recently used cache with a maximum size and per entry expiration.
+//
+// Once the cache is full the least recently used entry is evicted to make room for a new one. Expired entries are
+// removed when they are looked up. LRU is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // order holds the entries from most to least recently used.
+	entries map[K]*list.Element
+}
+
+// NewLRU creates a new cache that holds at most size entries.
+// It panics if size is less than one.
+func NewLRU[K comparable, V any](size int) *LRU[K, V] {
+	if size < 1 {
+		panic("expected size to be at least one")
+	}
+
+	return &LRU[K, V]{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value for the key and true if it is cached and has not expired at now.
+func (c *LRU[K, V]) Get(key K, now time.Time) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	e := el.Value.(*entry[K, V])
+	if !e.expires.IsZero() && !now.Before(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// Add caches the value for the key until ttl has passed since now, replacing any existing value.
+// A ttl of zero or less caches the value until it is evicted.
+func (c *LRU[K, V]) Add(key K, value V, ttl time.Duration, now time.Time) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value = value
+		e.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[K, V]).key)
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{
+		key:     key,
+		value:   value,
+		expires: expires,
+	})
+}
+
+// Remove removes the key from the cache if it exists.
+func (c *LRU[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of entries in the cache, including expired entries that were not yet removed.
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
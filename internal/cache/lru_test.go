@@ -0,0 +1,67 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"proto.zip/studio/mux/internal/cache"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	c := cache.NewLRU[string, int](2)
+
+	c.Add("a", 1, 0, now)
+	c.Add("b", 2, 0, now)
+
+	// Touch a so b is the least recently used
+	if v, ok := c.Get("a", now); !ok || v != 1 {
+		t.Errorf("Expected a to be 1, got %d", v)
+	}
+
+	c.Add("c", 3, 0, now)
+
+	if _, ok := c.Get("b", now); ok {
+		t.Error("Expected b to be evicted")
+	}
+
+	if v, ok := c.Get("a", now); !ok || v != 1 {
+		t.Errorf("Expected a to be 1, got %d", v)
+	}
+
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRUExpires(t *testing.T) {
+	now := time.Now()
+	c := cache.NewLRU[string, int](2)
+
+	c.Add("a", 1, time.Minute, now)
+
+	if _, ok := c.Get("a", now.Add(59*time.Second)); !ok {
+		t.Error("Expected a to not be expired")
+	}
+
+	if _, ok := c.Get("a", now.Add(time.Minute)); ok {
+		t.Error("Expected a to be expired")
+	}
+
+	if c.Len() != 0 {
+		t.Errorf("Expected expired entry to be removed, got %d entries", c.Len())
+	}
+}
+
+func TestLRURemove(t *testing.T) {
+	now := time.Now()
+	c := cache.NewLRU[string, int](2)
+
+	c.Add("a", 1, 0, now)
+	c.Add("a", 2, 0, now)
+	c.Remove("a")
+
+	if _, ok := c.Get("a", now); ok {
+		t.Error("Expected a to be removed")
+	}
+}
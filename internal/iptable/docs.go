@@ -0,0 +1,2 @@
+// Package iptable provides a lookup table for IP addresses and CIDR ranges that finds the most specific match.
+package iptable
@@ -0,0 +1,178 @@
+package iptable
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Entry holds the value of a single prefix in a Table.
+type Entry[V any] struct {
+	value *V
+}
+
+// Value returns the value associated with the entry.
+func (e *Entry[V]) Value() *V {
+	return e.value
+}
+
+// SetValue sets the value associated with the entry.
+func (e *Entry[V]) SetValue(value *V) {
+	e.value = value
+}
+
+// Table maps IP prefixes to values. Single addresses are stored as prefixes that cover all bits of the address.
+//
+// Lookups return the value of the longest prefix that contains the address, so a single address takes precedence
+// over any range that contains it and smaller ranges take precedence over larger ones.
+// Table is not safe for concurrent modification.
+type Table[V any] struct {
+	entries map[netip.Prefix]*Entry[V]
+	lengths [2][]int // lengths are the distinct prefix lengths in use for IPv4 and IPv6, longest first.
+}
+
+// New creates an empty table.
+func New[V any]() *Table[V] {
+	return &Table[V]{
+		entries: make(map[netip.Prefix]*Entry[V]),
+	}
+}
+
+// family returns the index of the address family of the address in the lengths of a table.
+func family(addr netip.Addr) int {
+	if addr.Is4() {
+		return 0
+	}
+	return 1
+}
+
+// Insert returns the entry for the prefix, creating it if it does not exist.
+// The prefix is masked and IPv4-mapped IPv6 prefixes are stored as IPv4 prefixes.
+func (t *Table[V]) Insert(prefix netip.Prefix) *Entry[V] {
+	prefix = normalize(prefix)
+
+	if entry, ok := t.entries[prefix]; ok {
+		return entry
+	}
+
+	entry := &Entry[V]{}
+	t.entries[prefix] = entry
+
+	f := family(prefix.Addr())
+	if !slices.Contains(t.lengths[f], prefix.Bits()) {
+		t.lengths[f] = append(t.lengths[f], prefix.Bits())
+		slices.SortFunc(t.lengths[f], func(a, b int) int { return b - a })
+	}
+
+	return entry
+}
+
+// Find returns the entry of the longest prefix that contains the address or nil if there is none.
+// Find does not allocate.
+func (t *Table[V]) Find(addr netip.Addr) *Entry[V] {
+	addr = addr.Unmap().WithZone("")
+
+	for _, bits := range t.lengths[family(addr)] {
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if entry, ok := t.entries[prefix]; ok {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of prefixes in the table.
+func (t *Table[V]) Len() int {
+	return len(t.entries)
+}
+
+// Values returns the values of all entries that have one, in no particular order.
+func (t *Table[V]) Values() []*V {
+	var values []*V
+	for _, entry := range t.entries {
+		if entry.value != nil {
+			values = append(values, entry.value)
+		}
+	}
+	return values
+}
+
+// normalize masks the prefix and converts IPv4-mapped IPv6 prefixes to IPv4.
+func normalize(prefix netip.Prefix) netip.Prefix {
+	addr := prefix.Addr()
+	bits := prefix.Bits()
+
+	if addr.Is4In6() && bits >= 96 {
+		addr = addr.Unmap()
+		bits -= 96
+	}
+
+	return netip.PrefixFrom(addr, bits).Masked()
+}
+
+// ParsePattern parses a host pattern that is an IP address or a CIDR range, such as "10.0.0.5", "[::1]",
+// "10.0.0.0/8" or "[fd00::]/8". Brackets around IPv6 addresses are optional.
+// It returns false if the pattern is not an IP pattern. Addresses with zones are not supported.
+func ParsePattern(pattern string) (netip.Prefix, bool) {
+	addrPart, bitsPart, hasBits := strings.Cut(pattern, "/")
+
+	if strings.HasPrefix(addrPart, "[") && strings.HasSuffix(addrPart, "]") {
+		addrPart = addrPart[1 : len(addrPart)-1]
+	}
+
+	addr, err := netip.ParseAddr(addrPart)
+	if err != nil || addr.Zone() != "" {
+		return netip.Prefix{}, false
+	}
+
+	if !hasBits {
+		return netip.PrefixFrom(addr, addr.BitLen()), true
+	}
+
+	prefix, err := netip.ParsePrefix(addr.String() + "/" + bitsPart)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+
+	return prefix, true
+}
+
+// ParseHost parses the value of a Host header that is an IP address with an optional port, such as "10.0.0.5",
+// "10.0.0.5:8080", "[::1]" or "[::1]:8080". It returns false if the host is not an IP address.
+//
+// Hostnames that cannot be IP addresses are rejected without allocating.
+func ParseHost(host string) (netip.Addr, bool) {
+	if host == "" {
+		return netip.Addr{}, false
+	}
+
+	if host[0] == '[' {
+		end := strings.IndexByte(host, ']')
+		if end < 0 || (end+1 < len(host) && host[end+1] != ':') {
+			return netip.Addr{}, false
+		}
+		host = host[1:end]
+	} else {
+		for i := 0; i < len(host); i++ {
+			if (host[i] < '0' || host[i] > '9') && host[i] != '.' && host[i] != ':' {
+				return netip.Addr{}, false
+			}
+		}
+
+		// IPv4 with a port, IPv6 addresses must be in brackets in a Host header
+		if colon := strings.IndexByte(host, ':'); colon >= 0 {
+			host = host[:colon]
+		}
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil || addr.Zone() != "" {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
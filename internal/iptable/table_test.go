@@ -0,0 +1,93 @@
+package iptable_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"proto.zip/studio/mux/internal/iptable"
+)
+
+func insertPattern(t *testing.T, table *iptable.Table[string], pattern string) {
+	t.Helper()
+
+	prefix, ok := iptable.ParsePattern(pattern)
+	if !ok {
+		t.Fatalf("Expected '%s' to be an IP pattern", pattern)
+	}
+
+	value := pattern
+	table.Insert(prefix).SetValue(&value)
+}
+
+func TestTableFind(t *testing.T) {
+	table := iptable.New[string]()
+
+	for _, pattern := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.3", "[::1]", "fd00::/8", "::ffff:192.168.0.0/112"} {
+		insertPattern(t, table, pattern)
+	}
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"10.2.3.4", "10.0.0.0/8"},
+		{"10.1.3.4", "10.1.0.0/16"},
+		{"10.1.2.3", "10.1.2.3"},
+		{"10.1.2.3:8080", "10.1.2.3"},
+		{"[::1]", "[::1]"},
+		{"[::1]:8080", "[::1]"},
+		{"[fd00::1]", "fd00::/8"},
+		{"[::ffff:10.1.2.3]", "10.1.2.3"},
+		{"192.168.1.1", "::ffff:192.168.0.0/112"},
+		{"11.0.0.1", ""},
+		{"[::2]", ""},
+	}
+
+	for _, test := range tests {
+		addr, ok := iptable.ParseHost(test.host)
+		if !ok {
+			t.Errorf("Expected '%s' to be an IP host", test.host)
+			continue
+		}
+
+		entry := table.Find(addr)
+
+		if test.expected == "" {
+			if entry != nil {
+				t.Errorf("Expected '%s' to not match, got '%s'", test.host, *entry.Value())
+			}
+		} else if entry == nil {
+			t.Errorf("Expected '%s' to match '%s', got nothing", test.host, test.expected)
+		} else if *entry.Value() != test.expected {
+			t.Errorf("Expected '%s' to match '%s', got '%s'", test.host, test.expected, *entry.Value())
+		}
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	for _, pattern := range []string{"example.com", "{ip}", "10.0.0.0/33", "fe80::1%eth0", "[::1"} {
+		if _, ok := iptable.ParsePattern(pattern); ok {
+			t.Errorf("Expected '%s' to not be an IP pattern", pattern)
+		}
+	}
+
+	if prefix, ok := iptable.ParsePattern("10.1.2.3/8"); !ok || prefix != netip.MustParsePrefix("10.1.2.3/8") {
+		t.Errorf("Unexpected prefix %s", prefix)
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	for _, host := range []string{"", "example.com", "123.example.com", "::1", "[::1]x", "[fe80::1%25eth0]", "1.2.3"} {
+		if _, ok := iptable.ParseHost(host); ok {
+			t.Errorf("Expected '%s' to not be an IP host", host)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		iptable.ParseHost("www.example.com")
+		iptable.ParseHost("10.0.0.5:8080")
+	})
+	if allocs != 0 {
+		t.Errorf("Expected ParseHost to not allocate, got %f allocations", allocs)
+	}
+}
@@ -0,0 +1,198 @@
+package negotiate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MediaRange represents a single entry of an Accept style header.
+type MediaRange struct {
+	Type    string  // Type is the main type, e.g. "text" or "*".
+	Subtype string  // Subtype is the sub type, e.g. "html" or "*".
+	Q       float64 // Q is the quality value in the range 0 to 1.
+}
+
+// specificity returns how specific the media range is.
+// Exact types are more specific than sub type wildcards, which are more specific than full wildcards.
+func (mr MediaRange) specificity() int {
+	switch {
+	case mr.Type == "*":
+		return 0
+	case mr.Subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Matches checks if the media type is covered by the media range.
+// The media type must be in "type/subtype" form, parameters are ignored.
+func (mr MediaRange) Matches(mediaType string) bool {
+	t, st := splitMediaType(mediaType)
+	return mr.covers(t, st)
+}
+
+// Covers checks if the media type of another media range, e.g. one returned by ParseMediaType, is covered by the
+// media range.
+func (mr MediaRange) Covers(other MediaRange) bool {
+	return mr.covers(other.Type, other.Subtype)
+}
+
+// covers checks if the lower case type and sub type are covered by the media range.
+func (mr MediaRange) covers(t, st string) bool {
+	if t == "" {
+		return false
+	}
+	if mr.Type != "*" && mr.Type != t {
+		return false
+	}
+	return mr.Subtype == "*" || mr.Subtype == st
+}
+
+// ParseMediaType parses a media type such as the value of a Content-Type header, parameters are ignored.
+// The second return value is false if the media type is malformed.
+func ParseMediaType(mediaType string) (MediaRange, bool) {
+	t, st := splitMediaType(mediaType)
+	return MediaRange{Type: t, Subtype: st, Q: 1}, t != ""
+}
+
+// splitMediaType splits a media type into its lower case type and sub type, discarding any parameters.
+// It returns empty strings if the media type is malformed.
+func splitMediaType(mediaType string) (string, string) {
+	if idx := strings.IndexByte(mediaType, ';'); idx >= 0 {
+		mediaType = mediaType[:idx]
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	t, st, ok := strings.Cut(mediaType, "/")
+	if !ok || t == "" || st == "" {
+		return "", ""
+	}
+	return t, st
+}
+
+// ParseAccept parses the value of an Accept header into media ranges.
+// Malformed entries are skipped and q-values that cannot be parsed are treated as 1.
+func ParseAccept(header string) []MediaRange {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+
+	ranges := make([]MediaRange, 0, strings.Count(header, ",")+1)
+
+	for rest := header; rest != ""; {
+		var mr MediaRange
+		var ok bool
+		if mr, rest, ok = nextRange(rest); ok {
+			ranges = append(ranges, mr)
+		}
+	}
+
+	return ranges
+}
+
+// nextRange parses the first entry of an Accept style header and returns it with the remaining entries.
+// The third return value is false if the entry is malformed.
+func nextRange(header string) (MediaRange, string, bool) {
+	entry, rest, _ := strings.Cut(header, ",")
+	entry, params, _ := strings.Cut(entry, ";")
+
+	t, st := splitMediaType(entry)
+	if t == "" {
+		return MediaRange{}, rest, false
+	}
+
+	mr := MediaRange{Type: t, Subtype: st, Q: 1}
+
+	for params != "" {
+		var param string
+		param, params, _ = strings.Cut(params, ";")
+
+		key, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q >= 0 && q <= 1 {
+			mr.Q = q
+		}
+	}
+
+	return mr, rest, true
+}
+
+// Quality returns the quality value the media ranges assign to a media type.
+// The most specific matching range wins. If no range matches, 0 is returned.
+func Quality(ranges []MediaRange, mediaType string) float64 {
+	best := -1
+	q := 0.0
+
+	for _, mr := range ranges {
+		if !mr.Matches(mediaType) {
+			continue
+		}
+		if s := mr.specificity(); s > best {
+			best = s
+			q = mr.Q
+		}
+	}
+
+	return q
+}
+
+// AcceptQuality returns the quality value an Accept header assigns to a media type like Quality, without parsing the
+// header into media ranges first. If the header has no media ranges, every media type is acceptable and 1 is
+// returned.
+func AcceptQuality(header string, mediaType MediaRange) float64 {
+	best := -1
+	q := 0.0
+	empty := true
+
+	for rest := header; rest != ""; {
+		var mr MediaRange
+		var ok bool
+		if mr, rest, ok = nextRange(rest); !ok {
+			continue
+		}
+
+		empty = false
+		if !mr.Covers(mediaType) {
+			continue
+		}
+		if s := mr.specificity(); s > best {
+			best = s
+			q = mr.Q
+		}
+	}
+
+	if empty {
+		return 1
+	}
+	return q
+}
+
+// Best returns the offered media type that is most acceptable according to the Accept header.
+//
+// Offers are compared by q-value and ties are resolved in favor of the offer listed first.
+// If the header is empty, the first offer is returned. If no offer is acceptable, the second return value is false.
+func Best(header string, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+
+	ranges := ParseAccept(header)
+	if len(ranges) == 0 {
+		return offers[0], true
+	}
+
+	bestOffer := ""
+	bestQ := 0.0
+
+	for _, offer := range offers {
+		if q := Quality(ranges, offer); q > bestQ {
+			bestOffer = offer
+			bestQ = q
+		}
+	}
+
+	return bestOffer, bestQ > 0
+}
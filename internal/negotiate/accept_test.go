@@ -0,0 +1,91 @@
+package negotiate_test
+
+import (
+	"testing"
+
+	"proto.zip/studio/mux/internal/negotiate"
+)
+
+func TestParseAccept(t *testing.T) {
+	ranges := negotiate.ParseAccept("text/html, application/json;q=0.5, */*;q=0.1, broken")
+
+	if len(ranges) != 3 {
+		t.Fatalf("Expected 3 media ranges, got %d", len(ranges))
+	}
+
+	if ranges[0].Type != "text" || ranges[0].Subtype != "html" || ranges[0].Q != 1 {
+		t.Errorf("Unexpected first media range: %+v", ranges[0])
+	}
+
+	if ranges[1].Q != 0.5 {
+		t.Errorf("Expected second q-value to be 0.5, got %f", ranges[1].Q)
+	}
+
+	if ranges[2].Type != "*" || ranges[2].Subtype != "*" {
+		t.Errorf("Unexpected last media range: %+v", ranges[2])
+	}
+}
+
+func TestQualitySpecificity(t *testing.T) {
+	ranges := negotiate.ParseAccept("text/*;q=0.3, text/html;q=0.7, */*;q=0.1")
+
+	if q := negotiate.Quality(ranges, "text/html"); q != 0.7 {
+		t.Errorf("Expected text/html to be 0.7, got %f", q)
+	}
+
+	if q := negotiate.Quality(ranges, "text/plain"); q != 0.3 {
+		t.Errorf("Expected text/plain to be 0.3, got %f", q)
+	}
+
+	if q := negotiate.Quality(ranges, "image/png"); q != 0.1 {
+		t.Errorf("Expected image/png to be 0.1, got %f", q)
+	}
+}
+
+func TestBest(t *testing.T) {
+	offers := []string{"application/problem+json", "text/html", "text/plain"}
+
+	if best, ok := negotiate.Best("", offers); !ok || best != offers[0] {
+		t.Errorf("Expected first offer for empty header, got '%s'", best)
+	}
+
+	if best, ok := negotiate.Best("text/html,application/xhtml+xml;q=0.9,*/*;q=0.8", offers); !ok || best != "text/html" {
+		t.Errorf("Expected text/html, got '%s'", best)
+	}
+
+	if best, ok := negotiate.Best("*/*", offers); !ok || best != offers[0] {
+		t.Errorf("Expected ties to resolve to the first offer, got '%s'", best)
+	}
+
+	if _, ok := negotiate.Best("image/png", offers); ok {
+		t.Error("Expected no acceptable offer")
+	}
+
+	if _, ok := negotiate.Best("text/plain;q=0", []string{"text/plain"}); ok {
+		t.Error("Expected q=0 to not be acceptable")
+	}
+}
+
+func TestAcceptQuality(t *testing.T) {
+	header := "text/*;q=0.3, text/html;Q=0.7, broken, */*;q=0.1"
+
+	for _, mediaType := range []string{"text/html", "text/plain", "image/png"} {
+		mr, ok := negotiate.ParseMediaType(mediaType)
+		if !ok {
+			t.Fatalf("Expected %s to be a valid media type", mediaType)
+		}
+
+		expected := negotiate.Quality(negotiate.ParseAccept(header), mediaType)
+		if q := negotiate.AcceptQuality(header, mr); q != expected {
+			t.Errorf("Expected %s to be %f, got %f", mediaType, expected, q)
+		}
+	}
+
+	if q := negotiate.AcceptQuality(" , broken", negotiate.MediaRange{Type: "text", Subtype: "html"}); q != 1 {
+		t.Errorf("Expected a header without media ranges to accept everything, got %f", q)
+	}
+
+	if _, ok := negotiate.ParseMediaType("text"); ok {
+		t.Error("Expected a media type without a sub type to be malformed")
+	}
+}
@@ -0,0 +1,2 @@
+// Package negotiate provides utilities for HTTP content negotiation based on media ranges and q-values.
+package negotiate
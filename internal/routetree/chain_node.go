@@ -0,0 +1,58 @@
+package routetree
+
+import "slices"
+
+// ChainNode represents a run of literal tokens collapsed into a single node.
+//
+// Route trees often contain long runs of literal segments with a single child each, e.g. /api/v1/internal.
+// Storing the run in one node saves a node, and its children map, per segment and shortens lookups.
+// It embeds a StandardNode to inherit common node functionalities.
+type ChainNode[H any] struct {
+	tokens []string
+	StandardNode[H]
+}
+
+// NewChainNode creates and initializes a new ChainNode with the given tokens.
+// It panics if there are no tokens.
+// It returns the node as an interface of type Node.
+func NewChainNode[H any](tokens []string) Node[H] {
+	if len(tokens) == 0 {
+		panic("expected at least one token")
+	}
+
+	return &ChainNode[H]{
+		tokens: slices.Clip(tokens),
+	}
+}
+
+// Match checks if the provided token matches the first token of the ChainNode.
+// The remaining tokens must be matched by the caller, see Tokens.
+func (n *ChainNode[H]) Match(token string) bool {
+	return n.tokens[0] == token
+}
+
+// Equal checks if the provided node is a ChainNode with the same tokens as this ChainNode.
+func (n *ChainNode[H]) Equal(b Node[H]) bool {
+	if chainNode, ok := b.(*ChainNode[H]); ok {
+		return slices.Equal(n.tokens, chainNode.tokens)
+	}
+	return false
+}
+
+// Tokens returns all of the tokens in the chain, in order.
+func (n *ChainNode[H]) Tokens() []string {
+	return n.tokens
+}
+
+// split shortens the chain to the first count tokens.
+// The remaining tokens, the children and the value are moved to a new ChainNode that becomes the only child.
+func (n *ChainNode[H]) split(count int) {
+	tail := &ChainNode[H]{
+		tokens:       n.tokens[count:],
+		StandardNode: n.StandardNode,
+	}
+
+	n.tokens = n.tokens[:count:count]
+	n.StandardNode = StandardNode[H]{}
+	n.AddChild(tail)
+}
@@ -0,0 +1,221 @@
+package routetree
+
+import (
+	"slices"
+
+	"proto.zip/studio/mux/internal/tokenizers"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+// literalScanLimit is the number of literal children up to which a linear scan is used instead of a hash lookup.
+const literalScanLimit = 8
+
+// compiledNode is a node in a compiled route tree.
+// Children and chain tokens are stored as ranges into the flat arrays of the Compiled tree.
+type compiledNode[H any] struct {
+	value                    *H
+	literalStart, literalEnd uint32
+	dynamicStart, dynamicEnd uint32
+	chainStart, chainEnd     uint32
+	dynamic                  bool
+	pattern                  *PatternNode[H]   // pattern captures the values of pattern nodes.
+	wide                     map[string]uint32 // wide indexes the literal children of nodes with many of them.
+}
+
+// compiledLiteral is a literal edge in a compiled route tree.
+type compiledLiteral struct {
+	token string
+	node  uint32
+}
+
+// compiledDynamic is a dynamic edge in a compiled route tree.
+type compiledDynamic[H any] struct {
+	match Node[H] // match is the original node used for matching. Nil matches every token.
+	node  uint32
+}
+
+// Compiled is an immutable route tree optimized for lookups.
+//
+// Nodes are stored in a flat array in breadth first order so siblings are next to each other in memory.
+// Literal children are stored in sorted tables that are scanned directly, nodes with many literal children also
+// get a hash index. Lookups do not use interface dispatch except for dynamic nodes that are not wildcards.
+type Compiled[H any] struct {
+	nodes    []compiledNode[H]
+	literals []compiledLiteral
+	dynamics []compiledDynamic[H]
+	chains   []string
+}
+
+// Compile flattens the tree starting at root into a Compiled tree.
+// The tree may still be modified afterwards but changes are not reflected in the Compiled tree.
+func Compile[H any](root Node[H]) *Compiled[H] {
+	c := &Compiled[H]{}
+	queue := []Node[H]{root}
+
+	// Each node is appended to the queue when its parent is compiled so the queue index is the node index
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		standard := node.(standardNode[H]).standard()
+
+		cn := compiledNode[H]{
+			value:   node.Value(),
+			dynamic: node.Dynamic(),
+		}
+
+		if pattern, ok := node.(*PatternNode[H]); ok {
+			cn.pattern = pattern
+		}
+
+		if chain, ok := node.(*ChainNode[H]); ok {
+			cn.chainStart = uint32(len(c.chains))
+			c.chains = append(c.chains, chain.tokens[1:]...)
+			cn.chainEnd = uint32(len(c.chains))
+		}
+
+		keys := make([]string, 0, len(standard.literalChildren))
+		for key := range standard.literalChildren {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		cn.literalStart = uint32(len(c.literals))
+		for _, key := range keys {
+			c.literals = append(c.literals, compiledLiteral{token: key, node: uint32(len(queue))})
+			queue = append(queue, standard.literalChildren[key])
+		}
+		cn.literalEnd = uint32(len(c.literals))
+
+		if len(keys) > literalScanLimit {
+			cn.wide = make(map[string]uint32, len(keys))
+			for _, literal := range c.literals[cn.literalStart:cn.literalEnd] {
+				cn.wide[literal.token] = literal.node
+			}
+		}
+
+		cn.dynamicStart = uint32(len(c.dynamics))
+		for _, child := range standard.allOtherChildren {
+			edge := compiledDynamic[H]{node: uint32(len(queue))}
+			if _, ok := child.(*WildcardNode[H]); !ok {
+				edge.match = child
+			}
+			c.dynamics = append(c.dynamics, edge)
+			queue = append(queue, child)
+		}
+		cn.dynamicEnd = uint32(len(c.dynamics))
+
+		c.nodes = append(c.nodes, cn)
+	}
+
+	return c
+}
+
+// child returns the index of the child of the node that matches the token or -1 if there is none.
+// If fold is true literals are matched ignoring the case of ASCII letters.
+func (c *Compiled[H]) child(node *compiledNode[H], token string, fold bool) int {
+	if node.wide != nil {
+		var idx uint32
+		var ok bool
+		if fold {
+			var buf [foldBufferSize]byte
+			idx, ok = node.wide[string(appendFold(buf[:0], token))]
+		} else {
+			idx, ok = node.wide[token]
+		}
+		if ok {
+			return int(idx)
+		}
+	} else {
+		literals := c.literals[node.literalStart:node.literalEnd]
+		for i := range literals {
+			if tokenEqual(token, literals[i].token, fold) {
+				return int(literals[i].node)
+			}
+		}
+	}
+
+	for _, edge := range c.dynamics[node.dynamicStart:node.dynamicEnd] {
+		if edge.match == nil {
+			return int(edge.node)
+		}
+
+		if pattern, ok := edge.match.(*PatternNode[H]); ok && fold {
+			if pattern.match(token, true) {
+				return int(edge.node)
+			}
+		} else if edge.match.Match(token) {
+			return int(edge.node)
+		}
+	}
+
+	return -1
+}
+
+// find follows the tokens returned by next down the tree and returns the value of the node for the last token.
+// Tokens that matched dynamic nodes are appended to values. If fold is true literals are matched ignoring the case
+// of ASCII letters.
+func (c *Compiled[H]) find(next func() (string, tokenizer.TokenType, error), values []string, fold bool) (*H, []string) {
+	start := len(values)
+
+	node := &c.nodes[0]
+	token, _, err := next()
+	for token != "" {
+		idx := c.child(node, token, fold)
+		if idx < 0 {
+			return nil, values[:start]
+		}
+		node = &c.nodes[idx]
+
+		if node.pattern != nil {
+			values, _ = node.pattern.appendValues(token, values, fold)
+		} else if node.dynamic {
+			values = append(values, token)
+		}
+
+		// The first token of a chain was matched by child, the rest must follow in order
+		for _, expected := range c.chains[node.chainStart:node.chainEnd] {
+			if token, _, err = next(); !tokenEqual(token, expected, fold) {
+				return nil, values[:start]
+			}
+		}
+
+		token, _, err = next()
+	}
+
+	if err != nil {
+		return nil, values[:start]
+	}
+
+	return node.value, values
+}
+
+// FindPath is the compiled equivalent of the FindPath function. It returns the value of the matching node
+// directly.
+func (c *Compiled[H]) FindPath(path string, values []string) (*H, []string) {
+	tok := tokenizers.NewPathStringTokenizer(path)
+	return c.find(tok.Next, values, false)
+}
+
+// FindPathFold is the compiled equivalent of the FindPathFold function. It returns the value of the matching node
+// directly.
+func (c *Compiled[H]) FindPathFold(path string, values []string) (*H, []string) {
+	tok := tokenizers.NewPathStringTokenizer(path)
+	return c.find(tok.Next, values, true)
+}
+
+// FindDomain is the compiled equivalent of the FindDomain function. It returns the value of the matching node
+// directly.
+func (c *Compiled[H]) FindDomain(hostname string, values []string) (*H, []string) {
+	tok := tokenizers.NewDomainStringTokenizer(hostname)
+	return c.find(tok.Next, values, false)
+}
+
+// Values returns the values of all nodes in the compiled tree in breadth first order.
+func (c *Compiled[H]) Values() []*H {
+	var values []*H
+	for i := range c.nodes {
+		if c.nodes[i].value != nil {
+			values = append(values, c.nodes[i].value)
+		}
+	}
+	return values
+}
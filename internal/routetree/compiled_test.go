@@ -0,0 +1,134 @@
+package routetree_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"proto.zip/studio/mux/internal/routetree"
+)
+
+func TestCompiledMatchesTree(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+
+	paths := []string{
+		"/",
+		"/api/v1/internal/admin/reports",
+		"/api/v1/internal/users",
+		"/api/v2/{id}/status",
+		"/docs/{id}",
+		"/docs/{id}/revisions/{revision}",
+		"/about",
+	}
+
+	// Enough literal siblings to use a binary search
+	for i := 0; i < 20; i++ {
+		paths = append(paths, fmt.Sprintf("/pages/page%02d", i))
+	}
+
+	for _, path := range paths {
+		insertPath(root, path, true)
+	}
+
+	compiled := routetree.Compile(root)
+
+	requests := append([]string{
+		"/api",
+		"/api/v1/internal",
+		"/api/v1/internal/admin/reports/extra",
+		"/docs//1",
+		"/pages/page20",
+		"/missing",
+	}, paths...)
+
+	for _, path := range requests {
+		request := strings.ReplaceAll(strings.ReplaceAll(path, "{id}", "123"), "{revision}", "456")
+
+		expectedNode, expectedValues := routetree.FindPath(root, request, nil)
+		value, values := compiled.FindPath(request, nil)
+
+		var expected *string
+		if expectedNode != nil {
+			expected = expectedNode.Value()
+		}
+
+		if value != expected {
+			t.Errorf("Expected compiled lookup of '%s' to match the tree", request)
+		}
+
+		if len(values) != len(expectedValues) {
+			t.Errorf("Expected compiled values of '%s' to be %v, got %v", request, expectedValues, values)
+		}
+	}
+
+	if len(compiled.Values()) != len(paths) {
+		t.Errorf("Expected %d values, got %d", len(paths), len(compiled.Values()))
+	}
+}
+
+func TestCompiledFindDomain(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+	value := "host"
+	routetree.Insert(root, pathSegments("/com/example/{sub}"), true).SetValue(&value)
+
+	compiled := routetree.Compile(root)
+
+	if v, values := compiled.FindDomain("test.example.com", nil); v != &value || len(values) != 1 || values[0] != "test" {
+		t.Errorf("Expected host to match with value 'test', got %v", values)
+	}
+
+	if v, _ := compiled.FindDomain("example.org", nil); v != nil {
+		t.Error("Expected host to not match")
+	}
+}
+
+func TestCompiledAllocations(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+	insertPath(root, "/docs/{id}/revisions/{revision}", true)
+
+	compiled := routetree.Compile(root)
+	buf := make([]string, 0, 4)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if v, _ := compiled.FindPath("/docs/1/revisions/2", buf[:0]); v == nil {
+			t.Error("Expected path to match")
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %f", allocs)
+	}
+}
+
+func benchmarkLookupWide(b *testing.B, compile bool) {
+	root := routetree.NewWildcardNode[string]()
+	for i := 0; i < 100; i++ {
+		insertPath(root, fmt.Sprintf("/section%02d/page/{id}", i), true)
+	}
+
+	compiled := routetree.Compile(root)
+	buf := make([]string, 0, 4)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if compile {
+			if v, _ := compiled.FindPath("/section42/page/123", buf[:0]); v == nil {
+				b.Error("got nil value")
+				return
+			}
+		} else if node, _ := routetree.FindPath(root, "/section42/page/123", buf[:0]); node == nil {
+			b.Error("got nil node")
+			return
+		}
+	}
+}
+
+func BenchmarkLookupWide_Compiled(b *testing.B) {
+	benchmarkLookupWide(b, true)
+}
+
+func BenchmarkLookupWide_Tree(b *testing.B) {
+	benchmarkLookupWide(b, false)
+}
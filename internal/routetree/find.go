@@ -0,0 +1,84 @@
+package routetree
+
+import (
+	"proto.zip/studio/mux/internal/tokenizers"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+// find follows the tokens returned by next down the tree starting at root and returns the node for the last token.
+// Tokens that matched dynamic nodes are appended to values. If fold is true literals are matched ignoring the case
+// of ASCII letters.
+//
+// The tokenizer is passed as a method value rather than an interface so it does not escape to the heap.
+func find[H any](root Node[H], next func() (string, tokenizer.TokenType, error), values []string, fold bool) (Node[H], []string) {
+	start := len(values)
+
+	node := root
+	token, _, err := next()
+	for node != nil && token != "" {
+		if fold {
+			node = childFold(node, token)
+		} else {
+			node = node.Child(token)
+		}
+		if node == nil {
+			break
+		}
+
+		if pattern, ok := node.(*PatternNode[H]); ok {
+			values, _ = pattern.appendValues(token, values, fold)
+		} else if node.Dynamic() {
+			values = append(values, token)
+		}
+
+		// The first token of a chain was matched by Child, the rest must follow in order
+		if chain, ok := node.(*ChainNode[H]); ok {
+			for _, expected := range chain.tokens[1:] {
+				if token, _, err = next(); !tokenEqual(token, expected, fold) {
+					node = nil
+					break
+				}
+			}
+		}
+
+		token, _, err = next()
+	}
+
+	if node == nil || err != nil {
+		return nil, values[:start]
+	}
+
+	return node, values
+}
+
+// FindPath follows the segments of a request path down the tree starting at root and returns the node for the
+// last segment. Segments that matched dynamic nodes are appended to values.
+//
+// Nil is returned if the path does not lead to a node or is malformed. In that case values is returned with its
+// original length.
+//
+// FindPath does not allocate unless values needs to grow.
+func FindPath[H any](root Node[H], path string, values []string) (Node[H], []string) {
+	tok := tokenizers.NewPathStringTokenizer(path)
+	return find(root, tok.Next, values, false)
+}
+
+// FindPathFold is the same as FindPath except that literal segments, and the literals of pattern segments, are
+// matched ignoring the case of ASCII letters. The tree must have been built with tokens folded by FoldCase.
+// Values are appended as they appear in the path.
+func FindPathFold[H any](root Node[H], path string, values []string) (Node[H], []string) {
+	tok := tokenizers.NewPathStringTokenizer(path)
+	return find(root, tok.Next, values, true)
+}
+
+// FindDomain follows the labels of a hostname, from the top level domain down, through the tree starting at root
+// and returns the node for the last label. Labels that matched dynamic nodes are appended to values.
+//
+// Nil is returned if the hostname does not lead to a node or is malformed. In that case values is returned with
+// its original length.
+//
+// FindDomain does not allocate unless values needs to grow.
+func FindDomain[H any](root Node[H], hostname string, values []string) (Node[H], []string) {
+	tok := tokenizers.NewDomainStringTokenizer(hostname)
+	return find(root, tok.Next, values, false)
+}
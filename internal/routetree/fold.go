@@ -0,0 +1,105 @@
+package routetree
+
+import "strings"
+
+// foldBufferSize is the size of the stack buffer used to fold tokens for literal lookups. Longer tokens are folded
+// into a heap allocated buffer.
+const foldBufferSize = 64
+
+// FoldCase returns s with ASCII letters converted to lower case.
+//
+// Trees that are searched with the Fold functions, such as FindPathFold, must have their literal tokens and the
+// literals of their pattern tokens folded with FoldCase when they are inserted. Only ASCII letters are folded so
+// folding never changes the length of a token.
+func FoldCase(s string) string {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 'A' && c <= 'Z' {
+			return string(appendFold(make([]byte, 0, len(s)), s))
+		}
+	}
+	return s
+}
+
+// appendFold appends s with ASCII letters converted to lower case to dst.
+func appendFold(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// equalFold reports whether token is equal to the folded string ignoring the case of ASCII letters in token.
+func equalFold(token, folded string) bool {
+	if len(token) != len(folded) {
+		return false
+	}
+
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != folded[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenEqual compares a request token with a token of the tree, ignoring the case of ASCII letters if fold is true.
+func tokenEqual(token, expected string, fold bool) bool {
+	if fold {
+		return equalFold(token, expected)
+	}
+	return token == expected
+}
+
+// hasPrefix is strings.HasPrefix, ignoring the case of ASCII letters in s if fold is true.
+func hasPrefix(s, prefix string, fold bool) bool {
+	return len(s) >= len(prefix) && tokenEqual(s[:len(prefix)], prefix, fold)
+}
+
+// hasSuffix is strings.HasSuffix, ignoring the case of ASCII letters in s if fold is true.
+func hasSuffix(s, suffix string, fold bool) bool {
+	return len(s) >= len(suffix) && tokenEqual(s[len(s)-len(suffix):], suffix, fold)
+}
+
+// lastIndex is strings.LastIndex, ignoring the case of ASCII letters in s if fold is true.
+func lastIndex(s, substr string, fold bool) int {
+	if !fold {
+		return strings.LastIndex(s, substr)
+	}
+
+	for i := len(s) - len(substr); i >= 0; i-- {
+		if tokenEqual(s[i:i+len(substr)], substr, fold) {
+			return i
+		}
+	}
+	return -1
+}
+
+// childFold is the case-insensitive equivalent of Child. The literal children of the node must be stored under
+// folded keys.
+func childFold[H any](node Node[H], token string) Node[H] {
+	standard := node.(standardNode[H]).standard()
+
+	var buf [foldBufferSize]byte
+	if child, ok := standard.literalChildren[string(appendFold(buf[:0], token))]; ok {
+		return child
+	}
+
+	for _, child := range standard.allOtherChildren {
+		if pattern, ok := child.(*PatternNode[H]); ok {
+			if pattern.match(token, true) {
+				return child
+			}
+		} else if child.Match(token) {
+			return child
+		}
+	}
+	return nil
+}
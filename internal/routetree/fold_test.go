@@ -0,0 +1,110 @@
+package routetree_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"proto.zip/studio/mux/internal/routetree"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+func TestFoldCase(t *testing.T) {
+	if s := routetree.FoldCase("Docs-ABC_ÄÖ"); s != "docs-abc_ÄÖ" {
+		t.Errorf("Expected only ASCII letters to be folded, got '%s'", s)
+	}
+
+	if s := routetree.FoldCase("docs"); s != "docs" {
+		t.Errorf("Expected lower case string to be unchanged, got '%s'", s)
+	}
+}
+
+func TestFindPathFold(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+
+	paths := []string{
+		"/Docs/ABC",
+		"/api/V1/Internal/users",
+		"/Files/{name}.JSON",
+		"/Users/{id}",
+	}
+
+	// Enough literal siblings to index the children of the compiled node
+	for i := 0; i < 20; i++ {
+		paths = append(paths, fmt.Sprintf("/Pages/Page%02d", i))
+	}
+
+	for _, path := range paths {
+		segments := pathSegments(path)
+		for i, segment := range segments {
+			if segment.Type != tokenizer.TokenTypeLabel {
+				segments[i].Token = routetree.FoldCase(segment.Token)
+			}
+		}
+
+		value := path
+		routetree.Insert(root, segments, true).SetValue(&value)
+	}
+
+	compiled := routetree.Compile(root)
+
+	tests := []struct {
+		path     string
+		expected string
+		values   []string
+	}{
+		{"/docs/abc", "/Docs/ABC", nil},
+		{"/DOCS/Abc", "/Docs/ABC", nil},
+		{"/API/v1/internal/USERS", "/api/V1/Internal/users", nil},
+		{"/files/Report.Final.json", "/Files/{name}.JSON", []string{"Report.Final"}},
+		{"/users/MixedCase", "/Users/{id}", []string{"MixedCase"}},
+		{"/pages/PAGE07", "/Pages/Page07", nil},
+		{"/docs/abd", "", nil},
+		{"/api/v1/internal/other", "", nil},
+	}
+
+	for _, test := range tests {
+		var value *string
+		node, values := routetree.FindPathFold(root, test.path, nil)
+		if node != nil {
+			value = node.Value()
+		}
+
+		compiledValue, compiledValues := compiled.FindPathFold(test.path, nil)
+
+		for name, result := range map[string]*string{"tree": value, "compiled": compiledValue} {
+			if test.expected == "" {
+				if result != nil {
+					t.Errorf("Expected %s lookup of '%s' to not match, got '%s'", name, test.path, *result)
+				}
+			} else if result == nil || *result != test.expected {
+				t.Errorf("Expected %s lookup of '%s' to match '%s'", name, test.path, test.expected)
+			}
+		}
+
+		if !slices.Equal(values, test.values) || !slices.Equal(compiledValues, test.values) {
+			t.Errorf("Expected values of '%s' to be %v, got %v and %v", test.path, test.values, values, compiledValues)
+		}
+	}
+
+	if node, _ := routetree.FindPath(root, "/Docs/ABC", nil); node != nil {
+		t.Error("Expected case-sensitive lookup to not match folded tokens")
+	}
+}
+
+func TestFindPathFoldAllocations(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+	insertPath(root, "/docs/{id}/revisions", true)
+	compiled := routetree.Compile(root)
+
+	values := make([]string, 0, 4)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		routetree.FindPathFold(root, "/DOCS/Abc/Revisions", values)
+		compiled.FindPathFold("/DOCS/Abc/Revisions", values)
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected case-insensitive lookups to not allocate, got %v allocations", allocs)
+	}
+}
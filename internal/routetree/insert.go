@@ -0,0 +1,115 @@
+package routetree
+
+import "proto.zip/studio/mux/pkg/tokenizer"
+
+// Segment is a single token of a pattern that is inserted into a route tree.
+type Segment struct {
+	Token string              // Token is the literal value, the label name or the whole segment for patterns.
+	Type  tokenizer.TokenType // Type is the token type returned by the pattern tokenizer.
+}
+
+// standardNode is implemented by all nodes that embed a StandardNode.
+type standardNode[H any] interface {
+	standard() *StandardNode[H]
+}
+
+// standard returns the StandardNode itself so embedding nodes expose it to the package.
+func (n *StandardNode[H]) standard() *StandardNode[H] {
+	return n
+}
+
+// newSegmentNode creates a node for a non-literal segment.
+func newSegmentNode[H any](segment Segment) Node[H] {
+	if segment.Type == tokenizer.TokenTypePattern {
+		return NewPatternNode[H](segment.Token)
+	}
+	return NewWildcardNode[H]()
+}
+
+// existingChild returns the child of the node that is equal to the candidate or nil if there is none.
+//
+// Unlike Child this does not match the token against dynamic children. A wildcard child would match any token
+// which is correct for lookups but would merge unrelated patterns when inserting.
+func existingChild[H any](node Node[H], candidate Node[H]) Node[H] {
+	for _, child := range node.(standardNode[H]).standard().allOtherChildren {
+		if child.Equal(candidate) {
+			return child
+		}
+	}
+	return nil
+}
+
+// Insert walks the tree from root following the segments, creating any nodes that are missing, and returns the
+// node for the last segment. If there are no segments root is returned.
+//
+// When compress is true runs of literal segments are stored in a single ChainNode and existing chains are split
+// as needed. Otherwise each literal segment gets its own LiteralNode.
+func Insert[H any](root Node[H], segments []Segment, compress bool) Node[H] {
+	node := root
+
+	for i := 0; i < len(segments); {
+		segment := segments[i]
+
+		if segment.Type != tokenizer.TokenTypeLiteral {
+			candidate := newSegmentNode[H](segment)
+			child := existingChild(node, candidate)
+			if child == nil {
+				node.AddChild(candidate)
+				child = candidate
+			}
+
+			node = child
+			i++
+			continue
+		}
+
+		child := node.(standardNode[H]).standard().literalChildren[segment.Token]
+
+		if child == nil {
+			end := i + 1
+			if compress {
+				for end < len(segments) && segments[end].Type == tokenizer.TokenTypeLiteral {
+					end++
+				}
+			}
+
+			if end-i > 1 {
+				tokens := make([]string, end-i)
+				for j := range tokens {
+					tokens[j] = segments[i+j].Token
+				}
+				child = NewChainNode[H](tokens)
+			} else {
+				child = NewLiteralNode[H](segment.Token)
+			}
+
+			node.AddChild(child)
+			node = child
+			i = end
+			continue
+		}
+
+		if chain, ok := child.(*ChainNode[H]); ok {
+			// Match as much of the chain as possible and split it where the segments diverge
+			matched := 1
+			for matched < len(chain.tokens) && i+matched < len(segments) &&
+				segments[i+matched].Type == tokenizer.TokenTypeLiteral &&
+				segments[i+matched].Token == chain.tokens[matched] {
+				matched++
+			}
+
+			if matched < len(chain.tokens) {
+				chain.split(matched)
+			}
+
+			node = chain
+			i += matched
+			continue
+		}
+
+		node = child
+		i++
+	}
+
+	return node
+}
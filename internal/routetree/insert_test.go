@@ -0,0 +1,224 @@
+package routetree_test
+
+import (
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+
+	"proto.zip/studio/mux/internal/routetree"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+// pathSegments converts a path pattern with {label} and mixed {name}.{ext} segments into route tree segments.
+func pathSegments(path string) []routetree.Segment {
+	var segments []routetree.Segment
+
+	for _, token := range strings.Split(strings.Trim(path, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		if strings.Count(token, "{") > 1 || (strings.Contains(token, "{") && (token[0] != '{' || token[len(token)-1] != '}')) {
+			segments = append(segments, routetree.Segment{Token: token, Type: tokenizer.TokenTypePattern})
+		} else if strings.HasPrefix(token, "{") {
+			segments = append(segments, routetree.Segment{Token: token[1 : len(token)-1], Type: tokenizer.TokenTypeLabel})
+		} else {
+			segments = append(segments, routetree.Segment{Token: token, Type: tokenizer.TokenTypeLiteral})
+		}
+	}
+
+	return segments
+}
+
+func insertPath(root routetree.Node[string], path string, compress bool) {
+	value := path
+	routetree.Insert(root, pathSegments(path), compress).SetValue(&value)
+}
+
+func expectPath(t *testing.T, root routetree.Node[string], path string, expected string) {
+	t.Helper()
+
+	node, _ := routetree.FindPath(root, path, nil)
+
+	if expected == "" {
+		if node != nil && node.Value() != nil {
+			t.Errorf("Expected '%s' to not match, got '%s'", path, *node.Value())
+		}
+		return
+	}
+
+	if node == nil || node.Value() == nil {
+		t.Errorf("Expected '%s' to match '%s', got nothing", path, expected)
+	} else if *node.Value() != expected {
+		t.Errorf("Expected '%s' to match '%s', got '%s'", path, expected, *node.Value())
+	}
+}
+
+func TestInsertChain(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+	insertPath(root, "/api/v1/internal/admin/reports", true)
+
+	child := root.Child("api")
+	chain, ok := child.(*routetree.ChainNode[string])
+	if !ok {
+		t.Fatalf("Expected a chain node, got %T", child)
+	}
+
+	if len(chain.Tokens()) != 5 {
+		t.Errorf("Expected 5 tokens in the chain, got %v", chain.Tokens())
+	}
+
+	expectPath(t, root, "/api/v1/internal/admin/reports", "/api/v1/internal/admin/reports")
+	expectPath(t, root, "/api/v1/internal/admin", "")
+	expectPath(t, root, "/api/v1/internal/admin/reports/more", "")
+	expectPath(t, root, "/api/v1/external/admin/reports", "")
+}
+
+func TestInsertChainSplit(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+
+	paths := []string{
+		"/api/v1/internal/admin/reports",
+		"/api/v1/internal/users",
+		"/api/v1",
+		"/api/v2/{id}/status",
+		"/api/v1/internal/admin/reports/{id}",
+	}
+
+	for _, path := range paths {
+		insertPath(root, path, true)
+	}
+
+	for _, path := range paths {
+		expectPath(t, root, strings.ReplaceAll(path, "{id}", "123"), path)
+	}
+
+	expectPath(t, root, "/api", "")
+	expectPath(t, root, "/api/v1/internal", "")
+	expectPath(t, root, "/api/v2/123", "")
+}
+
+func TestInsertUncompressed(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+	insertPath(root, "/api/v1/reports", false)
+
+	if _, ok := root.Child("api").(*routetree.LiteralNode[string]); !ok {
+		t.Errorf("Expected a literal node, got %T", root.Child("api"))
+	}
+
+	expectPath(t, root, "/api/v1/reports", "/api/v1/reports")
+}
+
+func TestInsertLiteralAndWildcardSiblings(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		root := routetree.NewWildcardNode[string]()
+
+		insertPath(root, "/{id}", compress)
+		insertPath(root, "/about", compress)
+		insertPath(root, "/id", compress)
+
+		expectPath(t, root, "/about", "/about")
+		expectPath(t, root, "/id", "/id")
+		expectPath(t, root, "/123", "/{id}")
+	}
+}
+
+func TestFindPathValues(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+	insertPath(root, "/docs/{id}/revisions/{revision}", true)
+
+	buf := make([]string, 1, 4)
+	buf[0] = "existing"
+
+	node, values := routetree.FindPath(root, "/docs/123/revisions/456", buf)
+	if node == nil {
+		t.Fatal("Expected node to be found")
+	}
+
+	if len(values) != 3 || values[0] != "existing" || values[1] != "123" || values[2] != "456" {
+		t.Errorf("Expected values to be appended, got %v", values)
+	}
+
+	if node, values := routetree.FindPath(root, "/docs/123/other/456", buf); node != nil || len(values) != 1 {
+		t.Errorf("Expected no match and original values, got %v", values)
+	}
+}
+
+func randomLabel(r *rand.Rand, length int) string {
+	const charset = "abcdefghijklmnopqrstuvwxyz"
+
+	label := make([]byte, length)
+	for i := range label {
+		label[i] = charset[r.Intn(len(charset))]
+	}
+	return string(label)
+}
+
+func benchmarkFindPath(b *testing.B, compress bool) {
+	root := routetree.NewWildcardNode[string]()
+	insertPath(root, "/api/v1/internal/admin/reports", compress)
+	insertPath(root, "/api/v1/internal/admin/users", compress)
+	insertPath(root, "/api/v2/internal/admin/reports", compress)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if node, _ := routetree.FindPath(root, "/api/v1/internal/admin/reports", nil); node == nil {
+			b.Error("got nil node")
+			return
+		}
+	}
+}
+
+func BenchmarkFindPath_Compressed(b *testing.B) {
+	benchmarkFindPath(b, true)
+}
+
+func BenchmarkFindPath_Uncompressed(b *testing.B) {
+	benchmarkFindPath(b, false)
+}
+
+// benchmarkInsertDomains measures the memory used by a host tree with many unique hosts.
+// Hostnames are inserted from the top level domain down the same way the mux does.
+func benchmarkInsertDomains(b *testing.B, compress bool) {
+	const hosts = 20000
+
+	r := rand.New(rand.NewSource(1))
+	segments := make([][]routetree.Segment, hosts)
+	for i := range segments {
+		segments[i] = []routetree.Segment{
+			{Token: "com", Type: tokenizer.TokenTypeLiteral},
+			{Token: "example", Type: tokenizer.TokenTypeLiteral},
+			{Token: randomLabel(r, 15), Type: tokenizer.TokenTypeLiteral},
+			{Token: randomLabel(r, 15), Type: tokenizer.TokenTypeLiteral},
+		}
+	}
+
+	var before, after runtime.MemStats
+	value := "host"
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		root := routetree.NewWildcardNode[string]()
+		for _, s := range segments {
+			routetree.Insert(root, s, compress).SetValue(&value)
+		}
+
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.TotalAlloc-before.TotalAlloc)/hosts, "B/host")
+		runtime.KeepAlive(root)
+	}
+}
+
+func BenchmarkInsertDomains_Compressed(b *testing.B) {
+	benchmarkInsertDomains(b, true)
+}
+
+func BenchmarkInsertDomains_Uncompressed(b *testing.B) {
+	benchmarkInsertDomains(b, false)
+}
@@ -1,7 +1,6 @@
 package routetree_test
 
 import (
-	"bytes"
 	"testing"
 
 	"proto.zip/studio/mux/internal/routetree"
@@ -9,8 +8,8 @@ import (
 
 const literalTestAString string = "test_a"
 
-var literalTestA []byte = []byte(literalTestAString)
-var literalTestB []byte = []byte("test_b")
+var literalTestA string = literalTestAString
+var literalTestB string = "test_b"
 
 func TestNodeLiteralChildren(t *testing.T) {
 	root := routetree.NewLiteralNode[any](literalTestA)
@@ -32,8 +31,8 @@ func TestNodeLiteralMatch(t *testing.T) {
 func TestNodeLiteralDoesNotMutateInput(t *testing.T) {
 	routetree.NewLiteralNode[any](literalTestA)
 
-	// The byte slice should not have been mutated
-	if !bytes.Equal(literalTestA, []byte(literalTestAString)) {
+	// The token should not have been mutated
+	if literalTestA != literalTestAString {
 		t.Errorf("Expected '%s' to match '%s'", literalTestA, literalTestAString)
 	}
 }
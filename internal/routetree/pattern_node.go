@@ -0,0 +1,146 @@
+package routetree
+
+import (
+	"slices"
+
+	"proto.zip/studio/mux/internal/tokenizers"
+)
+
+// PatternNode represents a node in the route tree that matches a token mixing literals and labels, such as
+// "{name}.{ext}", "v{version}" or "@{handle}". It captures one value per label.
+//
+// Tokens are split using the following rules:
+//   - The literal before the first label must be a prefix of the token and the literal after the last label must be a
+//     suffix of the token.
+//   - Every label matches at least one character.
+//   - Labels are greedy: each label takes the longest value that still allows the rest of the pattern to match.
+//     "archive.tar.gz" matched against "{name}.{ext}" gives name "archive.tar" and ext "gz".
+//
+// It embeds a StandardNode to inherit common node functionalities.
+type PatternNode[H any] struct {
+	prefix   string
+	literals []string // literals are the literals that follow each label, only the last one may be empty.
+	StandardNode[H]
+}
+
+// NewPatternNode creates and initializes a new PatternNode for a pattern segment such as "{name}.{ext}".
+// It panics if the pattern is not valid or has no labels.
+// It returns the node as an interface of type Node.
+func NewPatternNode[H any](pattern string) Node[H] {
+	prefix, parts, err := tokenizers.ParseSegmentPattern([]byte(pattern))
+	if err != nil {
+		panic(err)
+	}
+	if len(parts) == 0 {
+		panic("expected at least one label")
+	}
+
+	n := &PatternNode[H]{
+		prefix:   string(prefix),
+		literals: make([]string, len(parts)),
+	}
+	for i, part := range parts {
+		n.literals[i] = string(part.Literal)
+	}
+	return n
+}
+
+// split matches the remainder of a token against the labels followed by literals and appends the label values to
+// values if capture is true. Literals are compared ignoring the case of ASCII letters if fold is true.
+// It returns false if the token does not match, in which case values is returned with its original length.
+//
+// The labels are matched from the last one in a single pass. Moving the start of a label to the left never stops the
+// rest of the pattern from matching, so the rightmost separator that leaves at least one character for the labels
+// after it is the one that gives the earlier labels the longest values. Each literal is searched for once, which
+// keeps the cost linear in the length of the token.
+func split(literals []string, s string, values []string, capture bool, fold bool) ([]string, bool) {
+	last := len(literals) - 1
+
+	// end is the end of the value of the label being matched
+	end := len(s) - len(literals[last])
+	if end <= 0 || !hasSuffix(s, literals[last], fold) {
+		return values, false
+	}
+
+	// Values are appended from the last label and reversed once all labels matched
+	start := len(values)
+	for i := last - 1; i >= 0; i-- {
+		literal := literals[i]
+
+		separator := lastIndex(s[:end-1], literal, fold)
+		if separator <= 0 {
+			return values[:start], false
+		}
+
+		if capture {
+			values = append(values, s[separator+len(literal):end])
+		}
+		end = separator
+	}
+
+	if capture {
+		values = append(values, s[:end])
+		slices.Reverse(values[start:])
+	}
+	return values, true
+}
+
+// match checks if the token matches the pattern, ignoring the case of ASCII letters if fold is true.
+func (n *PatternNode[H]) match(token string, fold bool) bool {
+	if !hasPrefix(token, n.prefix, fold) {
+		return false
+	}
+	_, ok := split(n.literals, token[len(n.prefix):], nil, false, fold)
+	return ok
+}
+
+// appendValues appends the values of the labels in the token to values, ignoring the case of ASCII letters in the
+// literals if fold is true.
+func (n *PatternNode[H]) appendValues(token string, values []string, fold bool) ([]string, bool) {
+	if !hasPrefix(token, n.prefix, fold) {
+		return values, false
+	}
+	return split(n.literals, token[len(n.prefix):], values, true, fold)
+}
+
+// Match checks if the provided token matches the pattern of the PatternNode.
+func (n *PatternNode[H]) Match(token string) bool {
+	return n.match(token, false)
+}
+
+// AppendValues appends the values of the labels in the token to values.
+// It returns false if the token does not match, in which case values is returned with its original length.
+func (n *PatternNode[H]) AppendValues(token string, values []string) ([]string, bool) {
+	return n.appendValues(token, values, false)
+}
+
+// AppendValuesFold is the same as AppendValues except that the literals of the token are compared to the literals of
+// the pattern ignoring the case of ASCII letters. The pattern must have been folded with FoldCase.
+// The values are appended as they appear in the token.
+func (n *PatternNode[H]) AppendValuesFold(token string, values []string) ([]string, bool) {
+	return n.appendValues(token, values, true)
+}
+
+// Equal checks if the provided node is a PatternNode with the same literals. Label names are not compared, the same
+// way that all WildcardNodes are equal.
+func (n *PatternNode[H]) Equal(b Node[H]) bool {
+	if patternNode, ok := b.(*PatternNode[H]); ok {
+		return n.prefix == patternNode.prefix && slices.Equal(n.literals, patternNode.literals)
+	}
+	return false
+}
+
+// Dynamic indicates if the node represents a dynamic segment in the route tree.
+// For PatternNode, it always returns true.
+func (n *PatternNode[H]) Dynamic() bool {
+	return true
+}
+
+// literalLen returns the number of literal bytes in the pattern. Patterns with more literals are more specific.
+func (n *PatternNode[H]) literalLen() int {
+	l := len(n.prefix)
+	for _, literal := range n.literals {
+		l += len(literal)
+	}
+	return l
+}
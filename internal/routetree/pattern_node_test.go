@@ -0,0 +1,125 @@
+package routetree_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"proto.zip/studio/mux/internal/routetree"
+)
+
+func TestNodePatternChildren(t *testing.T) {
+	n := routetree.NewPatternNode[any]("{name}.{ext}")
+	NodeStandardAllChildTestHelper(t, n)
+}
+
+func TestNodePatternValues(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		token    string
+		expected []string
+	}{
+		{"{name}.{ext}", "report.pdf", []string{"report", "pdf"}},
+		{"{name}.{ext}", "archive.tar.gz", []string{"archive.tar", "gz"}},
+		{"{name}.{ext}", ".gz", nil},
+		{"{name}.{ext}", "report.", nil},
+		{"{name}.{ext}", "report", nil},
+		{"v{version}", "v2", []string{"2"}},
+		{"v{version}", "v", nil},
+		{"v{version}", "x2", nil},
+		{"@{handle}", "@proto", []string{"proto"}},
+		{"{a}.{b}-{c}", "x.y-z.w", []string{"x", "y", "z.w"}},
+		{"{a}-{b}.json", "1-2-3.json", []string{"1-2", "3"}},
+		{"{a}-{b}.json", "a--b.json", []string{"a-", "b"}},
+		{"{a}-{b}.json", "-b.json", nil},
+		{"{a}-{b}-{c}-{d}.json", "1-2-3-4-5.json", []string{"1-2", "3", "4", "5"}},
+		{"{a}-{b}-{c}-{d}.json", "1-2-3.json", nil},
+	}
+
+	for _, test := range tests {
+		n := routetree.NewPatternNode[any](test.pattern).(*routetree.PatternNode[any])
+
+		if n.Match(test.token) != (test.expected != nil) {
+			t.Errorf("Expected match of '%s' against '%s' to be %t", test.token, test.pattern, test.expected != nil)
+		}
+
+		values, ok := n.AppendValues(test.token, []string{"existing"})
+		if ok != (test.expected != nil) {
+			t.Errorf("Expected values of '%s' against '%s' to be %v", test.token, test.pattern, test.expected)
+		} else if !slices.Equal(values, append([]string{"existing"}, test.expected...)) {
+			t.Errorf("Expected values of '%s' against '%s' to be %v, got %v", test.token, test.pattern, test.expected, values[1:])
+		}
+	}
+}
+
+func TestNodePatternAdversarial(t *testing.T) {
+	n := routetree.NewPatternNode[any]("{a}-{b}-{c}-{d}.json").(*routetree.PatternNode[any])
+
+	// Every position is a candidate separator but the suffix never matches. Backtracking over the separators takes
+	// cubic time in the length of the token.
+	token := strings.Repeat("-x", 100000)
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if _, ok := n.AppendValues(token, nil); ok {
+			t.Fatal("Expected token without suffix to not match")
+		}
+		if _, ok := n.AppendValues(token+".json", nil); !ok {
+			t.Fatal("Expected token with suffix to match")
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected matching long tokens to take linear time, took %s", elapsed)
+	}
+}
+
+func TestNodePatternEqual(t *testing.T) {
+	n1 := routetree.NewPatternNode[any]("{name}.{ext}")
+
+	if !n1.Equal(routetree.NewPatternNode[any]("{a}.{b}")) {
+		t.Error("Expected patterns with different label names to be equal")
+	}
+
+	if n1.Equal(routetree.NewPatternNode[any]("{a}-{b}")) {
+		t.Error("Expected patterns with different literals to not be equal")
+	}
+
+	if n1.Equal(routetree.NewWildcardNode[any]()) {
+		t.Error("Expected pattern to not equal a wildcard")
+	}
+}
+
+func TestInsertPatternPrecedence(t *testing.T) {
+	root := routetree.NewWildcardNode[string]()
+	insertPath(root, "/files/{id}", true)
+	insertPath(root, "/files/{name}.{ext}", true)
+	insertPath(root, "/files/{name}.tar.{ext}", true)
+	insertPath(root, "/files/index.html", true)
+
+	compiled := routetree.Compile(root)
+
+	tests := []struct {
+		path     string
+		expected string
+		values   []string
+	}{
+		{"/files/index.html", "/files/index.html", nil},
+		{"/files/report.pdf", "/files/{name}.{ext}", []string{"report", "pdf"}},
+		{"/files/archive.tar.gz", "/files/{name}.tar.{ext}", []string{"archive", "gz"}},
+		{"/files/readme", "/files/{id}", []string{"readme"}},
+	}
+
+	for _, test := range tests {
+		node, values := routetree.FindPath(root, test.path, nil)
+		if node == nil || *node.Value() != test.expected || !slices.Equal(values, test.values) {
+			t.Errorf("Expected '%s' to match '%s' with %v, got %v", test.path, test.expected, test.values, values)
+		}
+
+		value, values := compiled.FindPath(test.path, nil)
+		if value == nil || *value != test.expected || !slices.Equal(values, test.values) {
+			t.Errorf("Expected compiled '%s' to match '%s' with %v, got %v", test.path, test.expected, test.values, values)
+		}
+	}
+}
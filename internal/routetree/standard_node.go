@@ -2,8 +2,7 @@ package routetree
 
 import (
 	"errors"
-
-	"proto.zip/studio/mux/pkg/tokenizer"
+	"slices"
 )
 
 // StandardNode represents a common node in the route tree.
@@ -15,16 +14,10 @@ type StandardNode[H any] struct {
 	handler          *H
 }
 
-// initChildren initializes the children maps for the StandardNode.
-func (n *StandardNode[H]) initChildren() {
-	n.literalChildren = make(map[string]Node[H])
-	n.allOtherChildren = make([]Node[H], 0)
-}
-
 // Child retrieves a child node that matches the provided token.
 // It first checks for literal matches and then checks other types of children.
-func (n *StandardNode[H]) Child(token tokenizer.Token) Node[H] {
-	if child, ok := n.literalChildren[string(token)]; ok {
+func (n *StandardNode[H]) Child(token string) Node[H] {
+	if child, ok := n.literalChildren[token]; ok {
 		return child
 	}
 	for _, child := range n.allOtherChildren {
@@ -35,15 +28,27 @@ func (n *StandardNode[H]) Child(token tokenizer.Token) Node[H] {
 	return nil
 }
 
+// literalKey returns the key a literal child is stored under and whether the node is a literal node.
+func literalKey[H any](node Node[H]) (string, bool) {
+	switch literal := node.(type) {
+	case *LiteralNode[H]:
+		return literal.token, true
+	case *ChainNode[H]:
+		return literal.tokens[0], true
+	}
+	return "", false
+}
+
 // AddChild adds a child node to the current node.
 func (n *StandardNode[H]) AddChild(child Node[H]) {
-	if literal, ok := child.(*LiteralNode[H]); ok {
-		key := string(literal.token)
-
+	if key, ok := literalKey(child); ok {
 		if _, duplicate := n.literalChildren[key]; duplicate {
 			panic(errors.New("duplicate path"))
 		}
 
+		if n.literalChildren == nil {
+			n.literalChildren = make(map[string]Node[H])
+		}
 		n.literalChildren[key] = child
 	} else {
 		for _, existingChild := range n.allOtherChildren {
@@ -52,10 +57,34 @@ func (n *StandardNode[H]) AddChild(child Node[H]) {
 			}
 		}
 
-		n.allOtherChildren = append(n.allOtherChildren, child)
+		n.allOtherChildren = insertDynamic(n.allOtherChildren, child)
 	}
 }
 
+// insertDynamic adds a non-literal child to the children in the order they are matched.
+//
+// Pattern nodes are more specific than wildcards so they are placed before them, with patterns that have more
+// literal characters first. All other nodes are matched in the order they were added.
+func insertDynamic[H any](children []Node[H], child Node[H]) []Node[H] {
+	pattern, ok := child.(*PatternNode[H])
+	if !ok {
+		return append(children, child)
+	}
+
+	for i, existing := range children {
+		switch existing := existing.(type) {
+		case *WildcardNode[H]:
+			return slices.Insert(children, i, child)
+		case *PatternNode[H]:
+			if existing.literalLen() < pattern.literalLen() {
+				return slices.Insert(children, i, child)
+			}
+		}
+	}
+
+	return append(children, child)
+}
+
 // Value returns the handler associated with the node.
 func (n *StandardNode[H]) Value() *H {
 	return n.handler
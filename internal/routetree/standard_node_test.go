@@ -4,14 +4,13 @@ import (
 	"testing"
 
 	"proto.zip/studio/mux/internal/routetree"
-	"proto.zip/studio/mux/pkg/tokenizer"
 )
 
 type SpyLiteralNode struct {
 	routetree.LiteralNode[any]
 }
 
-func NodeStandardChildTestHelper(t *testing.T, root routetree.Node[any], matchingToken tokenizer.Token, child routetree.Node[any]) {
+func NodeStandardChildTestHelper(t *testing.T, root routetree.Node[any], matchingToken string, child routetree.Node[any]) {
 	t.Helper()
 
 	if c := root.Child(matchingToken); c != nil {
@@ -37,7 +36,7 @@ func NodeStandardChildTestHelper(t *testing.T, root routetree.Node[any], matchin
 func NodeStandarLiteralChildTestHelper(t *testing.T, root routetree.Node[any]) *routetree.LiteralNode[any] {
 	t.Helper()
 
-	value := []byte("testLit")
+	value := "testLit"
 	child := routetree.NewLiteralNode[any](value)
 
 	NodeStandardChildTestHelper(t, root, value, child)
@@ -47,7 +46,7 @@ func NodeStandarLiteralChildTestHelper(t *testing.T, root routetree.Node[any]) *
 func NodeStandardWildcardChildTestHelper(t *testing.T, root routetree.Node[any]) {
 	t.Helper()
 
-	value := []byte("testWildcard") // Should not match the stirng used for any other test
+	value := "testWildcard" // Should not match the stirng used for any other test
 	child := routetree.NewWildcardNode[any]()
 
 	NodeStandardChildTestHelper(t, root, value, child)
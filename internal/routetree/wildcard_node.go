@@ -1,9 +1,5 @@
 package routetree
 
-import (
-	"proto.zip/studio/mux/pkg/tokenizer"
-)
-
 // WildcardNode represents a node in the route tree that matches any token.
 // It embeds a StandardNode to inherit common node functionalities.
 type WildcardNode[H any] struct {
@@ -14,13 +10,12 @@ type WildcardNode[H any] struct {
 // It returns the node as an interface of type Node.
 func NewWildcardNode[H any]() Node[H] {
 	n := &WildcardNode[H]{}
-	n.initChildren()
 	return n
 }
 
 // Match checks if the provided token matches the criteria of the WildcardNode.
 // Since it's a wildcard, it always returns true.
-func (n *WildcardNode[H]) Match(token tokenizer.Token) bool {
+func (n *WildcardNode[H]) Match(token string) bool {
 	return true
 }
 
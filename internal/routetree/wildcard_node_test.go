@@ -14,11 +14,11 @@ func TestNodeWildcardChildren(t *testing.T) {
 func TestNodeWildcardMatch(t *testing.T) {
 	n := routetree.NewWildcardNode[any]()
 
-	if !n.Match([]byte("a")) {
+	if !n.Match("a") {
 		t.Error("Expected node wildcard to match a short string")
 	}
 
-	if !n.Match([]byte("some longer string")) {
+	if !n.Match("some longer string") {
 		t.Error("Expected node wildcard to match a longer string")
 	}
 
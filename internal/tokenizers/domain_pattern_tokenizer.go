@@ -1,17 +1,22 @@
 package tokenizers
 
-import "proto.zip/studio/mux/pkg/tokenizer"
+import (
+	"bytes"
+
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
 
 // DomainPatternTokenizer is responsible for tokenizing domain patterns.
 // It processes the domain from right to left (from TLD to subdomain).
 type DomainPatternTokenizer struct {
 	domain []byte
 	pos    int
+	names  [][]byte // Label names seen so far, used to detect duplicates
 }
 
 // NewDomainPatternTokenizer initializes a new DomainPatternTokenizer with the given domain.
 //
-// DomainPatternTokenizer is different than DomainTokenizer since it allow expressions in the domain.
+// DomainPatternTokenizer is different than DomainStringTokenizer since it allow expressions in the domain.
 func NewDomainPatternTokenizer(domain []byte) *DomainPatternTokenizer {
 	t := &DomainPatternTokenizer{
 		domain: domain,
@@ -22,7 +27,11 @@ func NewDomainPatternTokenizer(domain []byte) *DomainPatternTokenizer {
 
 // Next returns the next token from the domain pattern.
 // It processes the domain from right to left and recognizes labels enclosed in curly braces.
-// If an error occurs during tokenization, it returns a TokenizerError.
+// Domain labels that are a single variable are returned as TokenTypeLabel with the variable name. Domain labels that
+// mix literals and variables, such as {tenant}-api, are returned whole as TokenTypePattern.
+// Variable names follow the rules of ParseSegmentPattern, optional labels are not supported in domains.
+// If an error occurs during tokenization, such as an invalid variable name or a name used twice in the pattern, it
+// returns a TokenizerError.
 func (t *DomainPatternTokenizer) Next() (tokenizer.Token, tokenizer.TokenType, error) {
 	if t.pos == -1 {
 		return nil, tokenizer.TokenTypeNil, nil
@@ -31,87 +40,84 @@ func (t *DomainPatternTokenizer) Next() (tokenizer.Token, tokenizer.TokenType, e
 	// Tokens must start with a dot '.' except the first one, which must never start with a dot
 	if t.pos == len(t.domain)-1 {
 		if t.domain[t.pos] == '.' {
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos:       t.pos,
-				Character: rune(t.domain[t.pos]),
-			}
+			return nil, tokenizer.TokenTypeNil, t.errorAt(t.pos, "domain cannot end with '.'")
 		}
 	} else if t.domain[t.pos] != '.' {
-		return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-			Pos:       t.pos,
-			Character: rune(t.domain[t.pos]),
-		}
+		return nil, tokenizer.TokenTypeNil, t.errorAt(t.pos, "labels must be separated by '.'")
 	} else {
 		t.pos--
 	}
 
-	// Variables have the format { label }
-	// We're going backwards so we look for the end first
-	if t.pos >= 0 && t.domain[t.pos] == '}' {
+	start := t.pos
+	pattern := false
+
+	for t.pos >= 0 && t.domain[t.pos] != '.' {
+		if t.domain[t.pos] == '{' || t.domain[t.pos] == '}' {
+			pattern = true
+		}
 		t.pos--
+	}
 
-		// Eat all trailing whitespace
-		for t.pos >= 0 && t.domain[t.pos] == ' ' {
-			t.pos--
+	if t.pos == start {
+		if t.pos == -1 {
+			// Domain starts with a '.'
+			return nil, tokenizer.TokenTypeNil, t.errorAt(t.pos, "domain cannot start with '.'")
+		} else {
+			// Domain has a double dot '..'
+			return nil, tokenizer.TokenTypeNil, t.errorAt(t.pos, "empty label")
 		}
+	}
 
-		start := t.pos + 1
+	ret := t.domain[t.pos+1 : start+1]
 
-		// Eat label until we hit a bracket or space
-		for t.pos >= 0 && t.domain[t.pos] != '{' && t.domain[t.pos] != ' ' {
-			t.pos--
-		}
+	if !pattern {
+		return ret, tokenizer.TokenTypeLiteral, nil
+	}
 
-		// We hit the end of the label part so store it now
-		ret := t.domain[t.pos : start+1]
+	// Labels with variables have the format { label } or mix literals and variables, e.g. {tenant}-api
+	prefix, parts, err := ParseSegmentPattern(ret)
+	if err != nil {
+		tokErr := err.(*tokenizer.TokenizerError)
+		tokErr.Pos += t.pos + 1
+		tokErr.Pattern = string(t.domain)
+		return nil, tokenizer.TokenTypeNil, tokErr
+	}
 
-		// Eat leading whitespace
-		for t.pos >= 0 && t.domain[t.pos] == ' ' {
-			t.pos--
-		}
+	if len(parts) == 0 {
+		return prefix, tokenizer.TokenTypeLiteral, nil
+	}
 
-		// We're past the end (start)
-		if t.pos == -1 {
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos: t.pos,
-			}
+	for _, part := range parts {
+		pos := t.pos + 1 + part.Pos
+		if _, _, optional := ParseLabel(part.Label); optional {
+			return nil, tokenizer.TokenTypeNil, t.errorAt(pos, "optional labels are not supported in domain patterns")
 		}
-
-		// Make sure we're ending on a '{'
-		if t.domain[t.pos] != '{' {
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos:       t.pos,
-				Character: rune(t.domain[t.pos]),
-			}
+		if err := t.addName(part.Label, pos); err != nil {
+			return nil, tokenizer.TokenTypeNil, err
 		}
-
-		t.pos--
-		return ret, tokenizer.TokenTypeLabel, nil
-
 	}
 
-	start := t.pos
-
-	for t.pos >= 0 && t.domain[t.pos] != '.' {
-		t.pos--
+	if len(prefix) == 0 && len(parts) == 1 && len(parts[0].Literal) == 0 {
+		return parts[0].Label, tokenizer.TokenTypeLabel, nil
 	}
 
-	if t.pos == start {
-		if t.pos == -1 {
-			// Domain starts with a '.'
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos: t.pos,
-			}
-		} else {
-			// Domain has a double dot '..'
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos:       t.pos,
-				Character: rune(t.domain[t.pos]),
-			}
+	return ret, tokenizer.TokenTypePattern, nil
+}
+
+// addName records a variable name and returns an error if it was already used in the pattern.
+func (t *DomainPatternTokenizer) addName(name []byte, pos int) error {
+	for _, seen := range t.names {
+		if bytes.Equal(seen, name) {
+			return t.errorAt(pos, "duplicate label name '"+string(name)+"'")
 		}
 	}
+	t.names = append(t.names, name)
+	return nil
+}
 
-	ret := t.domain[t.pos+1 : start+1]
-
-	return ret, tokenizer.TokenTypeLiteral, nil
+// errorAt returns a TokenizerError for the character at pos with the given reason.
+func (t *DomainPatternTokenizer) errorAt(pos int, reason string) error {
+	err := segmentError(t.domain, pos, reason)
+	err.Pattern = string(t.domain)
+	return err
 }
@@ -2,12 +2,33 @@ package tokenizers_test
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"proto.zip/studio/mux/internal/tokenizers"
 	"proto.zip/studio/mux/pkg/tokenizer"
 )
 
+func expectNextToken(label string, expectedValue tokenizer.Token, expectedType tokenizer.TokenType, tokenizer tokenizer.Tokenizer) error {
+	actualValue, actualType, err := tokenizer.Next()
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unexpected error getting %s: %s", label, err))
+	}
+
+	if actualType != expectedType {
+		return errors.New(fmt.Sprintf("Unexpected %s type to be '%s' got '%s'", label, expectedType, actualType))
+	}
+
+	if !bytes.Equal(actualValue, []byte(expectedValue)) {
+		return errors.New(fmt.Sprintf("Expected %s to be '%s' got '%s'", label, expectedValue, actualValue))
+	}
+
+	return nil
+}
+
 func TestDomainPatternTokenizer(t *testing.T) {
 	Domain := []byte("this.is.a.test")
 
@@ -135,6 +156,99 @@ func TestDomainPatternTokenizerEndingDot(t *testing.T) {
 	}
 }
 
+func TestDomainPatternTokenizerLabels(t *testing.T) {
+	Domain := []byte("pr-{number}.{ env }.example.com")
+
+	tok := tokenizers.NewDomainPatternTokenizer(Domain)
+
+	if err := expectNextToken("first token", []byte("com"), tokenizer.TokenTypeLiteral, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("second token", []byte("example"), tokenizer.TokenTypeLiteral, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("third token", []byte("env"), tokenizer.TokenTypeLabel, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("fourth token", []byte("pr-{number}"), tokenizer.TokenTypePattern, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("last token", nil, tokenizer.TokenTypeNil, tok); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDomainPatternTokenizerUnclosedLabel(t *testing.T) {
+	path := []byte("{tenant-api.example.com")
+	tok := tokenizers.NewDomainPatternTokenizer(path)
+
+	tok.Next()
+	tok.Next()
+
+	_, _, err := tok.Next()
+
+	tokenizerErr, ok := err.(*tokenizer.TokenizerError)
+	if !ok {
+		t.Fatalf("Expected error to be a TokenizerError, got: %v", err)
+	}
+
+	expectedPos := bytes.IndexByte(path, '.')
+	if tokenizerErr.Character != 0 || tokenizerErr.Pos != expectedPos {
+		t.Errorf("Expected unexpected end at %d, got '%c' at %d", expectedPos, tokenizerErr.Character, tokenizerErr.Pos)
+	}
+}
+
+func TestDomainPatternTokenizerInvalidLabels(t *testing.T) {
+	cases := []struct {
+		domain string
+		pos    int
+		reason string
+	}{
+		{"{}.example.com", 1, "empty label"},
+		{"{tenant.id}.example.com", 10, "unescaped '}'"},
+		{"{tenant!}.example.com", 1, "invalid label name"},
+		{"{tenant?}.example.com", 1, "optional labels are not supported"},
+		{"{env}.{env}.example.com", 1, "duplicate label name 'env'"},
+		{"{a}-{a}.example.com", 5, "duplicate label name 'a'"},
+		{"example..com", 7, "empty label"},
+	}
+
+	for _, c := range cases {
+		tok := tokenizers.NewDomainPatternTokenizer([]byte(c.domain))
+
+		var err error
+		for {
+			var token tokenizer.Token
+			token, _, err = tok.Next()
+			if err != nil || token == nil {
+				break
+			}
+		}
+
+		tokenizerErr, ok := err.(*tokenizer.TokenizerError)
+		if !ok {
+			t.Errorf("Expected a TokenizerError for '%s', got: %v", c.domain, err)
+			continue
+		}
+
+		if tokenizerErr.Pos != c.pos {
+			t.Errorf("Expected error at %d for '%s', got %d", c.pos, c.domain, tokenizerErr.Pos)
+		}
+
+		if tokenizerErr.Pattern != c.domain {
+			t.Errorf("Expected pattern '%s', got '%s'", c.domain, tokenizerErr.Pattern)
+		}
+
+		if !strings.Contains(tokenizerErr.Reason, c.reason) {
+			t.Errorf("Expected reason containing '%s' for '%s', got '%s'", c.reason, c.domain, tokenizerErr.Reason)
+		}
+	}
+}
+
 var longDomainPattern []byte
 var shortDomainPattern []byte = []byte("this.{is}.a.{domain}.for.{benchmarking}")
 
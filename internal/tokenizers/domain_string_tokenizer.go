@@ -0,0 +1,68 @@
+package tokenizers
+
+import "proto.zip/studio/mux/pkg/tokenizer"
+
+// DomainStringTokenizer is responsible for tokenizing domain names stored as strings.
+// It processes the domain from right to left and returns substrings of the domain so tokenizing does not allocate.
+//
+// DomainStringTokenizer is returned by value so it can live on the stack of the caller.
+type DomainStringTokenizer struct {
+	domain string
+	pos    int
+}
+
+// NewDomainStringTokenizer initializes a new DomainStringTokenizer with the given domain.
+func NewDomainStringTokenizer(domain string) DomainStringTokenizer {
+	return DomainStringTokenizer{
+		domain: domain,
+		pos:    len(domain) - 1,
+	}
+}
+
+// Next returns the next token from the domain.
+// It processes the domain from right to left, splitting it at dots.
+// An empty token and TokenTypeNil are returned once the start of the domain is reached.
+func (t *DomainStringTokenizer) Next() (string, tokenizer.TokenType, error) {
+	if t.pos == -1 {
+		return "", tokenizer.TokenTypeNil, nil
+	}
+
+	// Tokens must start with a dot '.' except the first one
+	if t.pos == len(t.domain)-1 {
+		if t.domain[t.pos] == '.' {
+			return "", tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
+				Pos:       t.pos,
+				Character: rune(t.domain[t.pos]),
+			}
+		}
+	} else if t.domain[t.pos] != '.' {
+		return "", tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
+			Pos:       t.pos,
+			Character: rune(t.domain[t.pos]),
+		}
+	} else {
+		t.pos--
+	}
+
+	start := t.pos
+
+	for t.pos >= 0 && t.domain[t.pos] != '.' {
+		t.pos--
+	}
+
+	if t.pos == start {
+		if t.pos == -1 {
+			// Domain starts with a '.'
+			return "", tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
+				Pos: t.pos,
+			}
+		}
+		// Domain has a double dot '..'
+		return "", tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
+			Pos:       t.pos,
+			Character: rune(t.domain[t.pos]),
+		}
+	}
+
+	return t.domain[t.pos+1 : start+1], tokenizer.TokenTypeLiteral, nil
+}
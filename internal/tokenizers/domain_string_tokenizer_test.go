@@ -0,0 +1,54 @@
+package tokenizers_test
+
+import (
+	"testing"
+
+	"proto.zip/studio/mux/internal/tokenizers"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+func TestDomainStringTokenizer(t *testing.T) {
+	tok := tokenizers.NewDomainStringTokenizer("this.is.a.test")
+
+	for _, expected := range []string{"test", "a", "is", "this"} {
+		token, tokType, err := tok.Next()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if token != expected || tokType != tokenizer.TokenTypeLiteral {
+			t.Errorf("Expected literal '%s', got %s '%s'", expected, tokType, token)
+		}
+	}
+
+	if token, tokType, err := tok.Next(); token != "" || tokType != tokenizer.TokenTypeNil || err != nil {
+		t.Errorf("Expected end of domain, got %s '%s' (%v)", tokType, token, err)
+	}
+}
+
+func TestDomainStringTokenizerDoubleDot(t *testing.T) {
+	tok := tokenizers.NewDomainStringTokenizer("some..test")
+	tok.Next()
+
+	_, _, err := tok.Next()
+
+	tokenizerErr, ok := err.(*tokenizer.TokenizerError)
+	if !ok {
+		t.Fatalf("Expected error to be a TokenizerError, got: %v", err)
+	}
+
+	if tokenizerErr.Character != '.' || tokenizerErr.Pos != 4 {
+		t.Errorf("Expected unexpected '.' at 4, got '%c' at %d", tokenizerErr.Character, tokenizerErr.Pos)
+	}
+}
+
+func TestDomainStringTokenizerAllocations(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		tok := tokenizers.NewDomainStringTokenizer("this.is.a.domain.for.benchmarking")
+		for token, _, _ := tok.Next(); token != ""; token, _, _ = tok.Next() {
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %f", allocs)
+	}
+}
@@ -1,16 +1,19 @@
 package tokenizers
 
 import (
+	"bytes"
+
 	"proto.zip/studio/mux/pkg/tokenizer"
 )
 
 // PathPatternTokenizer is responsible for tokenizing path patterns.
 // It processes the path from left to right, recognizing labels enclosed in curly braces and literals.
-// Unlike PathTokenizer, PathPatternTokenizer allows expressions in the path.
+// Unlike PathStringTokenizer, PathPatternTokenizer allows expressions in the path.
 type PathPatternTokenizer struct {
-	path []byte
-	len  int
-	pos  int
+	path  []byte
+	len   int
+	pos   int
+	names [][]byte // Label names seen so far, used to detect duplicates
 }
 
 // NewPathPatternTokenizer initializes a new PathPatternTokenizer with the given path.
@@ -24,7 +27,12 @@ func NewPathPatternTokenizer(path []byte) *PathPatternTokenizer {
 
 // Next returns the next token from the path pattern.
 // It processes the path from left to right, splitting it at slashes and recognizing labels enclosed in curly braces.
-// If an error occurs during tokenization, such as encountering unexpected characters, it returns a TokenizerError.
+// Segments that are a single label are returned as TokenTypeLabel with the label name. Segments that mix literals
+// and labels are returned whole as TokenTypePattern, see ParseSegmentPattern.
+// Literal braces are escaped by doubling them, segments with escaped braces and no labels are returned as
+// TokenTypeLiteral with the braces unescaped.
+// If an error occurs during tokenization, such as encountering unexpected characters, invalid label names or a label
+// name used twice in the pattern, it returns a TokenizerError.
 func (t *PathPatternTokenizer) Next() (tokenizer.Token, tokenizer.TokenType, error) {
 	if t.pos == t.len {
 		return nil, tokenizer.TokenTypeNil, nil
@@ -34,60 +42,18 @@ func (t *PathPatternTokenizer) Next() (tokenizer.Token, tokenizer.TokenType, err
 	if t.path[t.pos] == '/' {
 		t.pos++
 	} else if t.pos != 0 {
-		return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-			Pos:       t.pos,
-			Character: rune(t.path[t.pos]),
-		}
-	}
-
-	// Variables have the format { label }
-	if t.pos < t.len && t.path[t.pos] == '{' {
-		t.pos++
-
-		// Eat all leading whitespace
-		for t.pos < t.len && t.path[t.pos] == ' ' {
-			t.pos++
-		}
-
-		start := t.pos
-
-		// Eat label until we hit a bracket or space
-		for t.pos < t.len && t.path[t.pos] != '}' && t.path[t.pos] != ' ' {
-			t.pos++
-		}
-
-		// We hit the end of the label part so store it now
-		ret := t.path[start:t.pos]
-
-		// Eat trailing whitespace
-		for t.pos < t.len && t.path[t.pos] == ' ' {
-			t.pos++
-		}
-
-		// We're past the end
-		if t.pos == t.len {
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos: t.pos,
-			}
-		}
-
-		// Make sure we're ending on a '}'
-		if t.path[t.pos] != '}' {
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos:       t.pos,
-				Character: rune(t.path[t.pos]),
-			}
-		}
-
-		t.pos++
-		return ret, tokenizer.TokenTypeLabel, nil
+		return nil, tokenizer.TokenTypeNil, t.errorAt(t.pos, "segments must be separated by '/'")
 	}
 
 	// Not a variable, must be a litteral
 	// Read until we hit a slash
 	start := t.pos
+	pattern := false
 
 	for t.pos < t.len && t.path[t.pos] != '/' {
+		if t.path[t.pos] == '{' || t.path[t.pos] == '}' {
+			pattern = true
+		}
 		t.pos++
 	}
 
@@ -96,18 +62,62 @@ func (t *PathPatternTokenizer) Next() (tokenizer.Token, tokenizer.TokenType, err
 		if t.pos == t.len {
 			return nil, tokenizer.TokenTypeNil, nil
 		} else {
-			return nil, tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
-				Pos:       t.pos,
-				Character: rune(t.path[t.pos]),
-			}
+			return nil, tokenizer.TokenTypeNil, t.errorAt(t.pos, "empty segment")
 		}
 	}
 
 	ret := t.path[start:t.pos]
-	return ret, tokenizer.TokenTypeLiteral, nil
+
+	if !pattern {
+		return ret, tokenizer.TokenTypeLiteral, nil
+	}
+
+	// Segments with labels have the format { label } or mix literals and labels, e.g. {name}.{ext}
+	prefix, parts, err := ParseSegmentPattern(ret)
+	if err != nil {
+		tokErr := err.(*tokenizer.TokenizerError)
+		tokErr.Pos += start
+		tokErr.Pattern = string(t.path)
+		return nil, tokenizer.TokenTypeNil, tokErr
+	}
+
+	if len(parts) == 0 {
+		return prefix, tokenizer.TokenTypeLiteral, nil
+	}
+
+	for _, part := range parts {
+		name, _, _ := ParseLabel(part.Label)
+		if err := t.addName(name, start+part.Pos); err != nil {
+			return nil, tokenizer.TokenTypeNil, err
+		}
+	}
+
+	if len(prefix) == 0 && len(parts) == 1 && len(parts[0].Literal) == 0 {
+		return parts[0].Label, tokenizer.TokenTypeLabel, nil
+	}
+
+	return ret, tokenizer.TokenTypePattern, nil
 }
 
 // TrailingSlash checks if the path ends with a slash.
 func (t *PathPatternTokenizer) TrailingSlash() bool {
 	return t.len > 0 && t.path[t.len-1] == '/'
 }
+
+// addName records a label name and returns an error if it was already used in the pattern.
+func (t *PathPatternTokenizer) addName(name []byte, pos int) error {
+	for _, seen := range t.names {
+		if bytes.Equal(seen, name) {
+			return t.errorAt(pos, "duplicate label name '"+string(name)+"'")
+		}
+	}
+	t.names = append(t.names, name)
+	return nil
+}
+
+// errorAt returns a TokenizerError for the character at pos with the given reason.
+func (t *PathPatternTokenizer) errorAt(pos int, reason string) error {
+	err := segmentError(t.path, pos, reason)
+	err.Pattern = string(t.path)
+	return err
+}
@@ -2,6 +2,7 @@ package tokenizers_test
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"proto.zip/studio/mux/internal/tokenizers"
@@ -148,6 +149,177 @@ func TestPathPatternTokenizerNoClose(t *testing.T) {
 	}
 }
 
+func TestPathPatternTokenizerMixedSegments(t *testing.T) {
+	path := []byte("/files/{name}.{ext}/v{version}/@{ handle }")
+	tok := tokenizers.NewPathPatternTokenizer(path)
+
+	if err := expectNextToken("first token", []byte("files"), tokenizer.TokenTypeLiteral, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("second token", []byte("{name}.{ext}"), tokenizer.TokenTypePattern, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("third token", []byte("v{version}"), tokenizer.TokenTypePattern, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("fourth token", []byte("@{ handle }"), tokenizer.TokenTypePattern, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("last token", nil, tokenizer.TokenTypeNil, tok); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPathPatternTokenizerAdjacentLabels(t *testing.T) {
+	path := []byte("/files/{name}{ext}")
+	tok := tokenizers.NewPathPatternTokenizer(path)
+
+	if err := expectNextToken("first token", []byte("files"), tokenizer.TokenTypeLiteral, tok); err != nil {
+		t.Error(err)
+	}
+
+	_, _, err := tok.Next()
+
+	tokenizerErr, ok := err.(*tokenizer.TokenizerError)
+	if !ok {
+		t.Fatalf("Expected error to be a TokenizerError, got: %v", err)
+	}
+
+	if tokenizerErr.Character != '{' || tokenizerErr.Pos != bytes.LastIndexByte(path, '{') {
+		t.Errorf("Expected error for '{' at %d, got '%c' at %d", bytes.LastIndexByte(path, '{'), tokenizerErr.Character, tokenizerErr.Pos)
+	}
+}
+
+func TestParseSegmentPattern(t *testing.T) {
+	prefix, parts, err := tokenizers.ParseSegmentPattern([]byte("v{major}.{ minor }-beta"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if string(prefix) != "v" || len(parts) != 2 {
+		t.Fatalf("Expected prefix 'v' and 2 parts, got '%s' and %d parts", prefix, len(parts))
+	}
+
+	if string(parts[0].Label) != "major" || string(parts[0].Literal) != "." {
+		t.Errorf("Unexpected first part %s %s", parts[0].Label, parts[0].Literal)
+	}
+
+	if string(parts[1].Label) != "minor" || string(parts[1].Literal) != "-beta" {
+		t.Errorf("Unexpected second part %s %s", parts[1].Label, parts[1].Literal)
+	}
+
+	for _, segment := range []string{"a}b", "{a", "{a{b}}", "{a b}"} {
+		if _, _, err := tokenizers.ParseSegmentPattern([]byte(segment)); err == nil {
+			t.Errorf("Expected error for '%s'", segment)
+		}
+	}
+}
+
+func TestPathPatternTokenizerEscapedBraces(t *testing.T) {
+	path := []byte("docs/{{literal}}/{name}.{{json}}")
+	tok := tokenizers.NewPathPatternTokenizer(path)
+
+	if err := expectNextToken("first token", []byte("docs"), tokenizer.TokenTypeLiteral, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("second token", []byte("{literal}"), tokenizer.TokenTypeLiteral, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("third token", []byte("{name}.{{json}}"), tokenizer.TokenTypePattern, tok); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectNextToken("last token", nil, tokenizer.TokenTypeNil, tok); err != nil {
+		t.Error(err)
+	}
+
+	prefix, parts, err := tokenizers.ParseSegmentPattern([]byte("{{v{version}}}"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if string(prefix) != "{v" || len(parts) != 1 || string(parts[0].Label) != "version" || string(parts[0].Literal) != "}" {
+		t.Errorf("Unexpected result '%s' %v", prefix, parts)
+	}
+}
+
+func TestPathPatternTokenizerInvalidLabels(t *testing.T) {
+	cases := []struct {
+		path   string
+		pos    int
+		reason string
+	}{
+		{"a/{}", 3, "empty label"},
+		{"a/{ }", 4, "empty label"},
+		{"a/{id!}", 3, "invalid label name"},
+		{"a/{1st}", 3, "invalid label name"},
+		{"a/{id?x}", 3, "invalid label name"},
+		{"a/{=x}", 3, "invalid label name"},
+		{"a/b}", 3, "unescaped '}'"},
+		{"a/{id}/{id}", 8, "duplicate label name 'id'"},
+		{"a/{id?}/{id=1}", 9, "duplicate label name 'id'"},
+		{"a/{name}.{name}", 10, "duplicate label name 'name'"},
+		{"a//b", 2, "empty segment"},
+	}
+
+	for _, c := range cases {
+		tok := tokenizers.NewPathPatternTokenizer([]byte(c.path))
+
+		var err error
+		for {
+			var token tokenizer.Token
+			token, _, err = tok.Next()
+			if err != nil || token == nil {
+				break
+			}
+		}
+
+		tokenizerErr, ok := err.(*tokenizer.TokenizerError)
+		if !ok {
+			t.Errorf("Expected a TokenizerError for '%s', got: %v", c.path, err)
+			continue
+		}
+
+		if tokenizerErr.Pos != c.pos {
+			t.Errorf("Expected error at %d for '%s', got %d", c.pos, c.path, tokenizerErr.Pos)
+		}
+
+		if tokenizerErr.Pattern != c.path {
+			t.Errorf("Expected pattern '%s', got '%s'", c.path, tokenizerErr.Pattern)
+		}
+
+		if !strings.Contains(tokenizerErr.Reason, c.reason) {
+			t.Errorf("Expected reason containing '%s' for '%s', got '%s'", c.reason, c.path, tokenizerErr.Reason)
+		}
+
+		if !strings.Contains(err.Error(), c.path) || !strings.Contains(err.Error(), c.reason) {
+			t.Errorf("Expected message to contain the pattern and reason, got '%s'", err)
+		}
+	}
+}
+
+func TestPathPatternTokenizerLabelNames(t *testing.T) {
+	for _, path := range []string{"{id}", "{_id}", "{userID2}", "{page?}", "{page=1}", "{a}/{b}/{c_d}"} {
+		tok := tokenizers.NewPathPatternTokenizer([]byte(path))
+		for {
+			token, _, err := tok.Next()
+			if err != nil {
+				t.Errorf("Unexpected error for '%s': %s", path, err)
+				break
+			}
+			if token == nil {
+				break
+			}
+		}
+	}
+}
+
 var longPathPattern []byte
 var shortPathPattern []byte = []byte("this/{is}/a/{path}/{for}/benchmarking/")
 
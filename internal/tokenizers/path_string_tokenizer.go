@@ -0,0 +1,61 @@
+package tokenizers
+
+import (
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+// PathStringTokenizer is responsible for tokenizing request paths stored as strings.
+// It processes the path from left to right and returns substrings of the path so tokenizing does not allocate.
+//
+// PathStringTokenizer is returned by value so it can live on the stack of the caller.
+type PathStringTokenizer struct {
+	path string
+	pos  int
+}
+
+// NewPathStringTokenizer initializes a new PathStringTokenizer with the given path.
+func NewPathStringTokenizer(path string) PathStringTokenizer {
+	return PathStringTokenizer{
+		path: path,
+	}
+}
+
+// Next returns the next token from the path.
+// It processes the path from left to right, splitting it at slashes.
+// An empty token and TokenTypeNil are returned once the end of the path is reached.
+// If the path contains a double slash a TokenizerError is returned.
+func (t *PathStringTokenizer) Next() (string, tokenizer.TokenType, error) {
+	if t.pos == len(t.path) {
+		return "", tokenizer.TokenTypeNil, nil
+	}
+
+	// Initial character must be a slash except at the start of the string
+	if t.path[t.pos] == '/' {
+		t.pos++
+	} else if t.pos != 0 {
+		return "", tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
+			Pos:       t.pos,
+			Character: rune(t.path[t.pos]),
+		}
+	}
+
+	// Read until we hit a slash
+	start := t.pos
+
+	for t.pos < len(t.path) && t.path[t.pos] != '/' {
+		t.pos++
+	}
+
+	// We didn't progress, we're either at the end or had a double slash
+	if t.pos == start {
+		if t.pos == len(t.path) {
+			return "", tokenizer.TokenTypeNil, nil
+		}
+		return "", tokenizer.TokenTypeNil, &tokenizer.TokenizerError{
+			Pos:       t.pos,
+			Character: rune(t.path[t.pos]),
+		}
+	}
+
+	return t.path[start:t.pos], tokenizer.TokenTypeLiteral, nil
+}
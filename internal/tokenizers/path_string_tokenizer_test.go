@@ -0,0 +1,64 @@
+package tokenizers_test
+
+import (
+	"testing"
+
+	"proto.zip/studio/mux/internal/tokenizers"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+func TestPathStringTokenizer(t *testing.T) {
+	tok := tokenizers.NewPathStringTokenizer("/this/is/a/test/")
+
+	for _, expected := range []string{"this", "is", "a", "test"} {
+		token, tokType, err := tok.Next()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if token != expected || tokType != tokenizer.TokenTypeLiteral {
+			t.Errorf("Expected literal '%s', got %s '%s'", expected, tokType, token)
+		}
+	}
+
+	if token, tokType, err := tok.Next(); token != "" || tokType != tokenizer.TokenTypeNil || err != nil {
+		t.Errorf("Expected end of path, got %s '%s' (%v)", tokType, token, err)
+	}
+}
+
+func TestPathStringTokenizerDoubleSlash(t *testing.T) {
+	tok := tokenizers.NewPathStringTokenizer("some//test")
+	tok.Next()
+
+	_, _, err := tok.Next()
+
+	tokenizerErr, ok := err.(*tokenizer.TokenizerError)
+	if !ok {
+		t.Fatalf("Expected error to be a TokenizerError, got: %v", err)
+	}
+
+	if tokenizerErr.Character != '/' || tokenizerErr.Pos != 5 {
+		t.Errorf("Expected unexpected '/' at 5, got '%c' at %d", tokenizerErr.Character, tokenizerErr.Pos)
+	}
+}
+
+func TestPathStringTokenizerAllocations(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		tok := tokenizers.NewPathStringTokenizer("/this/is/a/path/for/benchmarking")
+		for token, _, _ := tok.Next(); token != ""; token, _, _ = tok.Next() {
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %f", allocs)
+	}
+}
+
+func BenchmarkPathStringTokenizer_6(b *testing.B) {
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		tok := tokenizers.NewPathStringTokenizer("/this/is/a/path/for/benchmarking")
+		for token, _, _ := tok.Next(); token != ""; token, _, _ = tok.Next() {
+		}
+	}
+}
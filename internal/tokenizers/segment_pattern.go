@@ -0,0 +1,165 @@
+package tokenizers
+
+import (
+	"bytes"
+
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+// SegmentPart is a label in a pattern segment and the literal that follows it.
+type SegmentPart struct {
+	Label   []byte // Label is the name of the label without braces or whitespace.
+	Literal []byte // Literal is the literal between the label and the next label or the end of the segment. Only the last literal may be empty.
+	Pos     int    // Pos is the position of the label name relative to the start of the segment.
+}
+
+// ParseSegmentPattern splits a single segment of a pattern, such as "{name}.{ext}" or "v{version}", into the literal
+// before the first label and the labels with the literals that follow them.
+//
+// Labels have the format { label } and must be separated by a literal, "{a}{b}" is an error since there is no way
+// to tell where the first value ends. Label names must start with a letter or an underscore followed by letters,
+// digits or underscores, optionally followed by the markers described in ParseLabel.
+// Literal braces are written twice, "{{" and "}}", and are returned unescaped.
+// Segments without labels are returned as a prefix with no parts.
+//
+// Errors are returned as a TokenizerError with the position relative to the start of the segment.
+func ParseSegmentPattern(segment []byte) ([]byte, []SegmentPart, error) {
+	pos := 0
+	n := len(segment)
+
+	literal := func() ([]byte, error) {
+		start := pos
+		var unescaped []byte
+
+		for pos < n {
+			c := segment[pos]
+			if c != '{' && c != '}' {
+				pos++
+				continue
+			}
+
+			if pos+1 == n || segment[pos+1] != c {
+				if c == '{' {
+					break
+				}
+				return nil, segmentError(segment, pos, "unescaped '}' outside of a label, use '}}' for a literal brace")
+			}
+
+			// Escaped brace, keep one of the two
+			unescaped = append(unescaped, segment[start:pos+1]...)
+			pos += 2
+			start = pos
+		}
+
+		if unescaped == nil {
+			return segment[start:pos], nil
+		}
+		return append(unescaped, segment[start:pos]...), nil
+	}
+
+	prefix, err := literal()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var parts []SegmentPart
+
+	for pos < n {
+		// Skip the opening brace and any leading whitespace
+		pos++
+		for pos < n && segment[pos] == ' ' {
+			pos++
+		}
+
+		start := pos
+		for pos < n && segment[pos] != '}' && segment[pos] != ' ' && segment[pos] != '{' {
+			pos++
+		}
+		label := segment[start:pos]
+
+		for pos < n && segment[pos] == ' ' {
+			pos++
+		}
+
+		if pos == n {
+			return nil, nil, segmentError(segment, pos, "label is missing a closing '}'")
+		}
+
+		if segment[pos] != '}' {
+			return nil, nil, segmentError(segment, pos, "label names cannot contain '{' or whitespace")
+		}
+
+		if name, _, _ := ParseLabel(label); !validLabelName(name) {
+			if len(label) == 0 {
+				return nil, nil, segmentError(segment, pos, "empty label")
+			}
+			return nil, nil, segmentError(segment, start, "invalid label name '"+string(label)+"', names must start with a letter or '_' followed by letters, digits or '_'")
+		}
+		pos++
+
+		// Adjacent labels cannot be split
+		if pos < n && segment[pos] == '{' && (pos+1 == n || segment[pos+1] != '{') {
+			return nil, nil, segmentError(segment, pos, "adjacent labels must be separated by a literal")
+		}
+
+		lit, err := literal()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		parts = append(parts, SegmentPart{
+			Label:   label,
+			Literal: lit,
+			Pos:     start,
+		})
+	}
+
+	return prefix, parts, nil
+}
+
+// ParseLabel splits the text of a label returned by PathPatternTokenizer into the parameter name and, for
+// optional labels, the default value.
+//
+// Labels ending in a question mark, such as {year?}, are optional without a default. Labels with an equals sign,
+// such as {month=01}, are optional with the value after the equals sign as the default. The default may be empty.
+func ParseLabel(label []byte) (name []byte, defaultValue []byte, optional bool) {
+	if idx := bytes.IndexByte(label, '='); idx >= 0 {
+		return label[:idx], label[idx+1:], true
+	}
+
+	if len(label) > 0 && label[len(label)-1] == '?' {
+		return label[:len(label)-1], nil, true
+	}
+
+	return label, nil, false
+}
+
+// validLabelName returns true if the name starts with an ASCII letter or an underscore and contains only ASCII
+// letters, digits and underscores.
+func validLabelName(name []byte) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// segmentError returns a TokenizerError for the character at pos, or the end of the segment if pos is outside it.
+func segmentError(segment []byte, pos int, reason string) *tokenizer.TokenizerError {
+	err := &tokenizer.TokenizerError{
+		Pos:    pos,
+		Reason: reason,
+	}
+	if pos >= 0 && pos < len(segment) {
+		err.Character = rune(segment[pos])
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+package host
+
+import (
+	"strings"
+
+	"proto.zip/studio/mux/pkg/predicate"
+	"proto.zip/studio/mux/pkg/resource"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+// Group is a set of routes on a host that share a path prefix and an error handler.
+//
+// Groups may be nested. When an error occurs serving a resource registered through a group the error handlers
+// are resolved innermost-first: the resource, see SetResourceErrorHandler, the group and its parents and then the host.
+type Group[RequestHandlerType any, ErrorHandlerType any] struct {
+	host         *Host[RequestHandlerType, ErrorHandlerType]
+	parent       *Group[RequestHandlerType, ErrorHandlerType]
+	prefix       string
+	ErrorHandler ErrorHandlerType // The function that is called when an error occurs. Nil will route the errors to the parent group or host.
+}
+
+// joinPath joins a group prefix and a path pattern with exactly one slash between them.
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	path = strings.TrimPrefix(path, "/")
+
+	if path == "" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+
+	return prefix + "/" + path
+}
+
+// Group creates a new route group on the host for the path prefix.
+// The prefix may contain expressions in the same format as any other path pattern.
+func (h *Host[RH, EH]) Group(prefix string) *Group[RH, EH] {
+	return &Group[RH, EH]{
+		host:   h,
+		prefix: joinPath("", prefix),
+	}
+}
+
+// ResourceGroup returns the group the resource was first registered through or nil if it was registered directly
+// on the host.
+func (h *Host[RH, EH]) ResourceGroup(r *resource.Resource[RH]) *Group[RH, EH] {
+	return h.routes.groups[r]
+}
+
+// SetResourceErrorHandler sets the function that is called when an error occurs serving the resource. Errors of
+// resources without an error handler are routed to their group or the host.
+//
+// Resource error handlers are part of the routes, so the handler applies to every host that shares the routes of
+// this host, see NewShared. ErrFrozen is returned if the host has been frozen, which includes hosts whose routes are
+// shared.
+//
+// The resource must belong to the host, e.g. returned by NewResource. SetResourceErrorHandler is not safe to call
+// while the host is serving requests.
+func (h *Host[RH, EH]) SetResourceErrorHandler(r *resource.Resource[RH], handler EH) error {
+	if h.routes.compiled != nil {
+		return ErrFrozen
+	}
+
+	if h.routes.errors == nil {
+		h.routes.errors = make(map[*resource.Resource[RH]]EH)
+	}
+	h.routes.errors[r] = handler
+	return nil
+}
+
+// ResourceErrorHandler returns the error handler of the resource and whether it has one.
+func (h *Host[RH, EH]) ResourceErrorHandler(r *resource.Resource[RH]) (EH, bool) {
+	handler, ok := h.routes.errors[r]
+	return handler, ok
+}
+
+// setResourceGroup records the group a resource was registered through unless it already belongs to a group.
+func (h *Host[RH, EH]) setResourceGroup(r *resource.Resource[RH], g *Group[RH, EH]) {
+	if h.routes.groups == nil {
+		h.routes.groups = make(map[*resource.Resource[RH]]*Group[RH, EH])
+	}
+	if _, ok := h.routes.groups[r]; !ok {
+		h.routes.groups[r] = g
+	}
+}
+
+// Group creates a new route group nested inside this group.
+// The prefix is relative to the prefix of this group.
+func (g *Group[RH, EH]) Group(prefix string) *Group[RH, EH] {
+	return &Group[RH, EH]{
+		host:   g.host,
+		parent: g,
+		prefix: joinPath(g.prefix, prefix),
+	}
+}
+
+// Parent returns the group this group is nested in or nil if the group was created directly on the host.
+func (g *Group[RH, EH]) Parent() *Group[RH, EH] {
+	return g.parent
+}
+
+// Host returns the host the group belongs to.
+func (g *Group[RH, EH]) Host() *Host[RH, EH] {
+	return g.host
+}
+
+// Prefix returns the full path prefix of the group, including the prefixes of any parent groups.
+func (g *Group[RH, EH]) Prefix() string {
+	return g.prefix
+}
+
+// NewResource fetches a resource under the group prefix or returns a new one if the resource does not exist yet.
+// It behaves the same as NewResource on the host with the group prefix prepended to the pattern.
+func (g *Group[RH, EH]) NewResource(pathPattern []byte) (*resource.Resource[RH], []tokenizer.Token, error) {
+	routes, err := g.host.newRoutes([]byte(joinPath(g.prefix, string(pathPattern))))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, rt := range routes {
+		g.host.setResourceGroup(rt.resource, g)
+	}
+
+	last := routes[len(routes)-1]
+	return last.resource, last.paramNames, nil
+}
+
+// Handle registers a new resource with the given method and path relative to the group prefix, associating it
+// with the provided handler.
+func (g *Group[RH, EH]) Handle(method, path string, handler RH) {
+	routes, err := g.host.newRoutes([]byte(joinPath(g.prefix, path)))
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rt := range routes {
+		g.host.setResourceGroup(rt.resource, g)
+		handleRoute(rt, method, handler)
+	}
+}
+
+// HandleWhen registers a conditional handler with the given method and path relative to the group prefix.
+// See HandleWhen on the host.
+func (g *Group[RH, EH]) HandleWhen(method, path string, p predicate.Predicate, priority int, handler RH) {
+	routes, err := g.host.newRoutes([]byte(joinPath(g.prefix, path)))
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rt := range routes {
+		g.host.setResourceGroup(rt.resource, g)
+		handleRouteWhen(rt, method, p, priority, handler)
+	}
+}
+
+// HandleMedia registers a handler variant for the media types of the request with the given method and path
+// relative to the group prefix. See HandleMedia on the host.
+func (g *Group[RH, EH]) HandleMedia(method, path string, media resource.MediaType, handler RH) {
+	routes, err := g.host.newRoutes([]byte(joinPath(g.prefix, path)))
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rt := range routes {
+		g.host.setResourceGroup(rt.resource, g)
+		handleRouteMedia(rt, method, media, handler)
+	}
+}
+
+// HandleSplit registers a split between weighted handler variants with the given method and path relative to the
+// group prefix. See HandleSplit on the host.
+func (g *Group[RH, EH]) HandleSplit(method, path string, split *resource.Split[RH]) {
+	routes, err := g.host.newRoutes([]byte(joinPath(g.prefix, path)))
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rt := range routes {
+		g.host.setResourceGroup(rt.resource, g)
+		handleRouteSplit(rt, method, split)
+	}
+}
@@ -5,21 +5,71 @@
 package host
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 
+	"golang.org/x/text/unicode/norm"
 	"proto.zip/studio/mux/internal/routetree"
 	"proto.zip/studio/mux/internal/tokenizers"
+	"proto.zip/studio/mux/pkg/predicate"
 	"proto.zip/studio/mux/pkg/resource"
 	"proto.zip/studio/mux/pkg/tokenizer"
 )
 
+// ErrFrozen is returned when attempting to register routes after the host or mux has been frozen.
+var ErrFrozen = errors.New("routes cannot be added after freezing")
+
+// ErrOptionalSegment is returned when an optional segment of a path pattern is followed by a required segment or
+// is not a whole segment.
+var ErrOptionalSegment = errors.New("optional segments must be whole segments at the end of the path pattern")
+
+// ErrRoutesRegistered is returned when changing how paths are matched after routes have been registered.
+var ErrRoutesRegistered = errors.New("path matching cannot be changed after routes are registered")
+
+// routeTable holds the route tree of a host, the groups its resources were registered through and the error handlers
+// of its resources.
+// A frozen route table is immutable and may be shared by many hosts, see NewShared.
+type routeTable[RH any, EH any] struct {
+	tree       routetree.Node[resource.Resource[RH]]
+	compiled   *routetree.Compiled[resource.Resource[RH]]
+	groups     map[*resource.Resource[RH]]*Group[RH, EH]
+	errors     map[*resource.Resource[RH]]EH // errors are the error handlers of resources, see SetResourceErrorHandler.
+	fold       bool                          // fold matches literals ignoring case, the tree stores them folded with routetree.FoldCase.
+	normalize  bool                          // normalize converts patterns and paths to the Unicode normalization form before matching.
+	form       norm.Form                     // form is the Unicode normalization form used if normalize is true.
+	registered bool                          // registered is set once the first route is inserted.
+}
+
+// normalizeString returns s in the normalization form of the route table, or s itself if normalization is disabled or
+// s is already normalized.
+func (t *routeTable[RH, EH]) normalizeString(s string) string {
+	// QuickSpanString does not allocate, unlike IsNormalString
+	if !t.normalize || t.form.QuickSpanString(s) == len(s) {
+		return s
+	}
+	return t.form.String(s)
+}
+
+// newRouteTable creates an empty route table.
+func newRouteTable[RH any, EH any]() *routeTable[RH, EH] {
+	return &routeTable[RH, EH]{
+		tree: routetree.NewWildcardNode[resource.Resource[RH]](),
+	}
+}
+
 // Host structs represent a host entry in the routing tree and are used to match
 // incoming requests for a specific host.
 type Host[RequestHandlerType any, ErrorHandlerType any] struct {
-	routes       routetree.Node[resource.Resource[RequestHandlerType]]
-	params       []tokenizer.Token
+	routes       *routeTable[RequestHandlerType, ErrorHandlerType]
+	params       []string
+	pattern      string
 	ErrorHandler ErrorHandlerType // The function that is called when an error occurs. Nil will route the errors to the default handler.
+	Logger       *slog.Logger     // The logger used for errors on this host. Nil will use the logger of the mux.
+	Policy       *Policy          // The redirects and security headers applied to requests for this host. Nil applies none.
+	Locales      *Locales         // The languages the routes of this host are served in under a /{lang} prefix. Nil serves routes without a prefix.
 }
 
 // New creates a new Host entry with the specific request and error handler types.
@@ -28,117 +78,550 @@ type Host[RequestHandlerType any, ErrorHandlerType any] struct {
 // Most of the time you will want to use NewHost() on the mux implementation instead.
 func New[RH any, EH any]() *Host[RH, EH] {
 	return &Host[RH, EH]{
-		routes: routetree.NewWildcardNode[resource.Resource[RH]](),
+		routes: newRouteTable[RH, EH](),
 	}
 }
 
+// paramNames converts pattern parameter tokens to strings.
+func paramNames(params []tokenizer.Token) []string {
+	var names []string
+	if len(params) > 0 {
+		names = make([]string, len(params))
+		for i, param := range params {
+			names[i] = string(param)
+		}
+	}
+	return names
+}
+
 // NewWithParams creates a new host with pattern parameters.
 func NewWithParams[RH any, EH any](params []tokenizer.Token) *Host[RH, EH] {
 	return &Host[RH, EH]{
-		params: params,
-		routes: routetree.NewWildcardNode[resource.Resource[RH]](),
+		params: paramNames(params),
+		routes: newRouteTable[RH, EH](),
+	}
+}
+
+// NewWithPattern creates a new host with the pattern it was registered with and the pattern parameters.
+func NewWithPattern[RH any, EH any](pattern string, params []tokenizer.Token) *Host[RH, EH] {
+	h := NewWithParams[RH, EH](params)
+	h.pattern = pattern
+	return h
+}
+
+// NewShared creates a new host with the pattern it was registered with and the pattern parameters that shares the
+// routes of template.
+//
+// The template is frozen if it is not already, so the shared routes can no longer change. Only the pattern, the
+// parameters, the error handler, the logger, the policy and the locales are stored per host, which keeps the memory
+// used by each host small when many hosts serve identical routes. The error handler, logger, policy and locales of the
+// template are not copied.
+func NewShared[RH any, EH any](template *Host[RH, EH], pattern string, params []tokenizer.Token) *Host[RH, EH] {
+	template.Freeze()
+
+	return &Host[RH, EH]{
+		params:  paramNames(params),
+		pattern: pattern,
+		routes:  template.routes,
 	}
 }
 
+// SharesRoutes returns true if the host and other use the same routes, either because they are the same host or
+// because one was created from the other with NewShared.
+func (h *Host[RH, EH]) SharesRoutes(other *Host[RH, EH]) bool {
+	return other != nil && h.routes == other.routes
+}
+
+// Pattern returns the host pattern the host was registered with.
+// The default host has an empty pattern.
+func (h *Host[RH, EH]) Pattern() string {
+	return h.pattern
+}
+
 // Resource fetches a resource under the host route tree.
 // It won't create a new resources. If the path does not match any resources then this method
 // will return nil.
 //
 // On success, it will also return the tokens (if any) that matched the path expressions.
+// Use AppendResource to fetch a resource without allocating.
 func (h *Host[RH, EH]) Resource(path []byte) (*resource.Resource[RH], []tokenizer.Token) {
-	tok := tokenizers.NewPathTokenizer(path)
+	r, paramValues := h.AppendResource(string(path), nil)
+	return r, toTokens(paramValues)
+}
 
-	var paramValues []tokenizer.Token
+// toTokens converts parameter values to tokens. It returns nil if there are no values.
+func toTokens(values []string) []tokenizer.Token {
+	if len(values) == 0 {
+		return nil
+	}
 
-	node := h.routes
-	token, _, _ := tok.Next()
-	for node != nil && token != nil {
-		node = node.Child(token)
+	tokens := make([]tokenizer.Token, len(values))
+	for i, value := range values {
+		tokens[i] = tokenizer.Token(value)
+	}
+	return tokens
+}
 
-		if node != nil && node.Dynamic() {
-			if paramValues == nil {
-				paramValues = make([]tokenizer.Token, 0, 1)
-			}
-			paramValues = append(paramValues, token)
-		}
+// AppendResource is the same as Resource except that the path is a string and the values that matched the path
+// expressions are appended to paramValues. Callers can provide a slice with enough capacity to fetch a resource
+// without allocating.
+func (h *Host[RH, EH]) AppendResource(path string, paramValues []string) (*resource.Resource[RH], []string) {
+	path = h.routes.normalizeString(path)
 
-		token, _, _ = tok.Next()
+	if h.routes.compiled != nil {
+		if h.routes.fold {
+			return h.routes.compiled.FindPathFold(path, paramValues)
+		}
+		return h.routes.compiled.FindPath(path, paramValues)
 	}
 
+	var node routetree.Node[resource.Resource[RH]]
+	if h.routes.fold {
+		node, paramValues = routetree.FindPathFold(h.routes.tree, path, paramValues)
+	} else {
+		node, paramValues = routetree.FindPath(h.routes.tree, path, paramValues)
+	}
 	if node == nil {
-		return nil, nil
+		return nil, paramValues
 	}
 
 	return node.Value(), paramValues
 }
 
+// route is a resource for one of the paths a path pattern expands to and the parameters of that path.
+type route[RH any, EH any] struct {
+	resource   *resource.Resource[RH]
+	paramNames []tokenizer.Token
+	defaults   []string // defaults are the values of the last parameters, which are not part of the path.
+}
+
 // NewResources fetches a resource under the host or returns a new one if the resource does not
 // exist yet.
 // This method takes a pattern and will return an error if the expressions cannot be parsed.
+// ErrFrozen is returned if the host has been frozen.
+//
+// If the pattern has optional segments the resources for the shorter paths are created as well and the resource for
+// the full path is returned. The resources for the shorter paths have the pattern without the segments they omit,
+// e.g. /reports/{year?} and /reports for /reports/{year?}/{month=01}.
 //
 // On success, it will also return the tokens (if any) that matched the path expressions.
 func (h *Host[RH, EH]) NewResource(pathPattern []byte) (*resource.Resource[RH], []tokenizer.Token, error) {
+	routes, err := h.newRoutes(pathPattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last := routes[len(routes)-1]
+	return last.resource, last.paramNames, nil
+}
+
+// newRoutes fetches or creates the resources for every path the pattern expands to, from the shortest to the
+// longest. Patterns without optional segments expand to a single path.
+//
+// Optional segments have the format {name?}, or {name=default} to set a default value for the parameter when the
+// segment is not part of the path. They must be whole segments at the end of the pattern.
+func (h *Host[RH, EH]) newRoutes(pathPattern []byte) ([]route[RH, EH], error) {
+	if h.routes.compiled != nil {
+		return nil, ErrFrozen
+	}
+
 	tok := tokenizers.NewPathPatternTokenizer(pathPattern)
 
-	node := h.routes
 	token, tokenType, err := tok.Next()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	var paramNames []tokenizer.Token
+	var segments []routetree.Segment
+	var segmentNames [][]tokenizer.Token
+	var segmentDefaults [][]byte
+	firstOptional := -1
 
 	for token != nil {
-		parent := node
+		var names []tokenizer.Token
+		var defaultValue []byte
+		optional := false
 
-		if tokenType == tokenizer.TokenTypeLabel {
-			if paramNames == nil {
-				paramNames = make([]tokenizer.Token, 0, 1)
+		switch tokenType {
+		case tokenizer.TokenTypeLabel:
+			token, defaultValue, optional = tokenizers.ParseLabel(token)
+			names = append(names, token)
+		case tokenizer.TokenTypePattern:
+			// The tokenizer already validated the pattern
+			_, parts, _ := tokenizers.ParseSegmentPattern(token)
+			for _, part := range parts {
+				if _, _, partOptional := tokenizers.ParseLabel(part.Label); partOptional {
+					return nil, ErrOptionalSegment
+				}
+				names = append(names, part.Label)
 			}
-			paramNames = append(paramNames, token)
 		}
 
-		node = node.Child(token)
-		if node == nil {
-			if tokenType == tokenizer.TokenTypeLabel {
-				node = routetree.NewWildcardNode[resource.Resource[RH]]()
-			} else {
-				node = routetree.NewLiteralNode[resource.Resource[RH]](token)
+		if optional {
+			if firstOptional < 0 {
+				firstOptional = len(segments)
 			}
-			parent.AddChild(node)
+		} else if firstOptional >= 0 {
+			return nil, ErrOptionalSegment
 		}
 
+		segment := routetree.Segment{
+			Token: string(token),
+			Type:  tokenType,
+		}
+		if tokenType != tokenizer.TokenTypeLabel {
+			segment.Token = h.routes.normalizeString(segment.Token)
+			if h.routes.fold {
+				segment.Token = routetree.FoldCase(segment.Token)
+			}
+		}
+
+		segments = append(segments, segment)
+		segmentNames = append(segmentNames, names)
+		segmentDefaults = append(segmentDefaults, defaultValue)
+
 		token, tokenType, err = tok.Next()
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 
-	r := node.Value()
-	if r == nil {
-		r = resource.New[RH]()
-		node.SetValue(r)
+	if firstOptional < 0 {
+		firstOptional = len(segments)
+	}
+
+	routes := make([]route[RH, EH], 0, len(segments)-firstOptional+1)
+	h.routes.registered = true
+
+	for depth := firstOptional; depth <= len(segments); depth++ {
+		node := routetree.Insert(h.routes.tree, segments[:depth], true)
+
+		r := node.Value()
+		if r == nil {
+			r = resource.NewWithPattern[RH](patternPrefix(pathPattern, depth, len(segments)))
+			node.SetValue(r)
+		}
+
+		rt := route[RH, EH]{resource: r}
+		for _, names := range segmentNames[:depth] {
+			rt.paramNames = append(rt.paramNames, names...)
+		}
+
+		// Optional parameters that are not part of the path only exist if they have a default value
+		for i := depth; i < len(segments); i++ {
+			if segmentDefaults[i] != nil {
+				rt.paramNames = append(rt.paramNames, segmentNames[i]...)
+				rt.defaults = append(rt.defaults, h.routes.normalizeString(string(segmentDefaults[i])))
+			}
+		}
+
+		routes = append(routes, rt)
+	}
+
+	return routes, nil
+}
+
+// patternPrefix returns the part of the path pattern made of its first depth segments, which is the pattern of the
+// resource for that depth. The whole pattern is returned if depth is the number of segments.
+func patternPrefix(pathPattern []byte, depth int, segments int) string {
+	if depth == segments {
+		return string(pathPattern)
+	}
+
+	end := 0
+	for ; depth > 0; depth-- {
+		// Skip the separator, or the first character of a pattern without a leading slash
+		end++
+		for end < len(pathPattern) && pathPattern[end] != '/' {
+			end++
+		}
 	}
-	return r, paramNames, nil
+
+	if end == 0 {
+		return "/"
+	}
+	return string(pathPattern[:end])
 }
 
 // Handle registers a new resource with the given method and path, associating it with the provided handler.
 // It also sets parameter names if any are present in the path.
+//
+// Optional segments at the end of the path, such as /reports/{year?}/{month=01}, register the handler for each
+// path the pattern expands to. See NewResource.
 func (h *Host[RH, EH]) Handle(method, path string, handler RH) {
-	resource, paramNames, err := h.NewResource([]byte(path))
+	routes, err := h.newRoutes([]byte(path))
 
 	if err != nil {
 		panic(err)
 	}
 
+	for _, rt := range routes {
+		handleRoute(rt, method, handler)
+	}
+}
+
+// handleRoute associates the handler, parameter names and default values with the method on the resource of the
+// route.
+func handleRoute[RH any, EH any](rt route[RH, EH], method string, handler RH) {
 	// User supplied input so we convert to upper case for ease of use.
 	methodUpper := strings.ToUpper(method)
 
-	if len(paramNames) > 0 {
-		resource.SetParamNames(methodUpper, paramNames)
+	setRouteParams(rt, methodUpper)
+	rt.resource.HandleMethod(methodUpper, handler)
+}
+
+// handleRouteWhen associates the conditional handler, parameter names and default values with the method on the
+// resource of the route.
+func handleRouteWhen[RH any, EH any](rt route[RH, EH], method string, p predicate.Predicate, priority int, handler RH) {
+	methodUpper := strings.ToUpper(method)
+
+	setRouteParams(rt, methodUpper)
+	rt.resource.HandleMethodWhen(methodUpper, p, priority, handler)
+}
+
+// handleRouteMedia associates the media type variant, parameter names and default values with the method on the
+// resource of the route.
+func handleRouteMedia[RH any, EH any](rt route[RH, EH], method string, media resource.MediaType, handler RH) {
+	methodUpper := strings.ToUpper(method)
+
+	setRouteParams(rt, methodUpper)
+	rt.resource.HandleMethodMedia(methodUpper, media, handler)
+}
+
+// handleRouteSplit associates the split, parameter names and default values with the method on the resource of the
+// route.
+func handleRouteSplit[RH any, EH any](rt route[RH, EH], method string, split *resource.Split[RH]) {
+	methodUpper := strings.ToUpper(method)
+
+	setRouteParams(rt, methodUpper)
+	rt.resource.HandleMethodSplit(methodUpper, split)
+}
+
+// setRouteParams sets the parameter names and default values of the route for the method. All handlers of a method
+// share its parameters, so it panics if another handler for the method was registered with different parameter
+// names or default values.
+func setRouteParams[RH any, EH any](rt route[RH, EH], methodUpper string) {
+	if !rt.resource.HasMethod(methodUpper) {
+		if len(rt.paramNames) > 0 {
+			rt.resource.SetParamNames(methodUpper, rt.paramNames)
+		}
+		if len(rt.defaults) > 0 {
+			rt.resource.SetParamDefaults(methodUpper, rt.defaults)
+		}
+		return
+	}
+
+	names := rt.resource.ParamNames(methodUpper)
+	sameNames := len(names) == len(rt.paramNames)
+	for i := 0; sameNames && i < len(names); i++ {
+		sameNames = names[i] == string(rt.paramNames[i])
+	}
+
+	if !sameNames || !slices.Equal(rt.resource.ParamDefaults(methodUpper), rt.defaults) {
+		panic(fmt.Errorf("conflicting parameters for %s %s: already registered with %v and defaults %v", methodUpper, rt.resource.Pattern(), names, rt.resource.ParamDefaults(methodUpper)))
+	}
+}
+
+// HandleWhen registers a conditional handler with the given method and path that only serves requests that satisfy
+// the predicate, e.g. predicate.Header("Accept-Version", "2"). Conditional handlers are evaluated in priority order,
+// highest first, before the handler registered with Handle for the same method and path.
+func (h *Host[RH, EH]) HandleWhen(method, path string, p predicate.Predicate, priority int, handler RH) {
+	routes, err := h.newRoutes([]byte(path))
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rt := range routes {
+		handleRouteWhen(rt, method, p, priority, handler)
+	}
+}
+
+// HandleMedia registers a handler variant with the given method and path for the media types of the request, e.g.
+// resource.MediaType{Consumes: "application/json"} for JSON bodies or resource.MediaType{Produces: "text/html"} for
+// browsers. The variant is chosen by the Content-Type header and negotiated with the q-values of the Accept header.
+// If no variant or handler registered with Handle serves a request the mux responds with 415 Unsupported Media Type
+// or 406 Not Acceptable. See resource.Resource.MatchMethod.
+func (h *Host[RH, EH]) HandleMedia(method, path string, media resource.MediaType, handler RH) {
+	routes, err := h.newRoutes([]byte(path))
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rt := range routes {
+		handleRouteMedia(rt, method, media, handler)
+	}
+}
+
+// HandleSplit registers a split between weighted handler variants with the given method and path, in place of a
+// handler registered with Handle, e.g. for canary releases or A/B tests. The same split can be registered on many
+// paths and its weights changed with SetWeights while requests are being served. The name of the variant that serves
+// a request is available with muxcontext.Variant.
+func (h *Host[RH, EH]) HandleSplit(method, path string, split *resource.Split[RH]) {
+	routes, err := h.newRoutes([]byte(path))
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rt := range routes {
+		handleRouteSplit(rt, method, split)
+	}
+}
+
+// Freeze compiles the route tree of the host into an immutable matcher that is used for all further lookups.
+// Registering routes after freezing returns ErrFrozen. Calling Freeze more than once has no effect.
+//
+// Freeze is not safe to call while the host is serving requests. Hosts that share routes are frozen together.
+func (h *Host[RH, EH]) Freeze() {
+	if h.routes.compiled != nil {
+		return
+	}
+
+	h.routes.compiled = routetree.Compile(h.routes.tree)
+	h.routes.tree = nil
+}
+
+// SetCaseInsensitive sets whether literal path segments, and the literals of segments such as {name}.json, are matched
+// ignoring the case of ASCII letters, so /Docs/ABC and /docs/abc reach the same resource. Parameter values are
+// returned as they appear in the request path.
+//
+// Patterns that only differ in case register the same resource. Use Policy.RedirectPathCase to redirect requests to
+// the casing of the pattern, see CanonicalPath.
+//
+// It must be called before any routes are registered, otherwise ErrRoutesRegistered is returned. ErrFrozen is
+// returned if the host has been frozen.
+func (h *Host[RH, EH]) SetCaseInsensitive(insensitive bool) error {
+	if h.routes.compiled != nil {
+		return ErrFrozen
 	}
+	if h.routes.registered {
+		return ErrRoutesRegistered
+	}
+
+	h.routes.fold = insensitive
+	return nil
+}
+
+// CaseInsensitive returns true if literal path segments are matched ignoring case, see SetCaseInsensitive.
+func (h *Host[RH, EH]) CaseInsensitive() bool {
+	return h.routes.fold
+}
+
+// SetNormalization sets whether paths are converted to a Unicode normalization form before they are matched, so
+// /café matches whether the client sends the precomposed or the decomposed é. Literal path segments and default
+// values are normalized when routes are registered and request paths when they are looked up, which also normalizes
+// the parameter values. Most clients send NFC so norm.NFC is the recommended form.
+//
+// It must be called before any routes are registered, otherwise ErrRoutesRegistered is returned. ErrFrozen is
+// returned if the host has been frozen.
+func (h *Host[RH, EH]) SetNormalization(normalize bool, form norm.Form) error {
+	if h.routes.compiled != nil {
+		return ErrFrozen
+	}
+	if h.routes.registered {
+		return ErrRoutesRegistered
+	}
+
+	h.routes.normalize = normalize
+	h.routes.form = form
+	return nil
+}
+
+// Normalization returns the Unicode normalization form of paths and whether normalization is enabled, see
+// SetNormalization.
+func (h *Host[RH, EH]) Normalization() (norm.Form, bool) {
+	return h.routes.form, h.routes.normalize
+}
+
+// CanonicalPath returns the path with the literals of the pattern of the resource it matches in the case they were
+// registered with. Parameter values are kept as they appear in path. If the host normalizes paths the result is
+// normalized as well.
+//
+// The path is returned unchanged if the host is not case-insensitive or no resource matches.
+func (h *Host[RH, EH]) CanonicalPath(path string) string {
+	if !h.routes.fold {
+		return path
+	}
+
+	normalized := h.routes.normalizeString(path)
+
+	r, _ := h.AppendResource(normalized, nil)
+	if r == nil {
+		return path
+	}
+
+	patternTok := tokenizers.NewPathPatternTokenizer([]byte(r.Pattern()))
+	pathTok := tokenizers.NewPathStringTokenizer(normalized)
+
+	var b strings.Builder
+	b.Grow(len(normalized))
+
+	// Optional segments may leave pattern tokens unused but every path token has a pattern token
+	for {
+		token, _, err := pathTok.Next()
+		if err != nil {
+			return path
+		}
+		if token == "" {
+			break
+		}
+
+		patternToken, patternType, err := patternTok.Next()
+		if err != nil || patternToken == nil {
+			return path
+		}
+
+		b.WriteByte('/')
+
+		switch patternType {
+		case tokenizer.TokenTypeLiteral:
+			b.WriteString(h.routes.normalizeString(string(patternToken)))
+		case tokenizer.TokenTypePattern:
+			b.WriteString(canonicalSegment(h.routes.normalizeString(string(patternToken)), token))
+		default:
+			b.WriteString(token)
+		}
+	}
+
+	if b.Len() == 0 || strings.HasSuffix(path, "/") {
+		b.WriteByte('/')
+	}
+
+	return b.String()
+}
+
+// canonicalSegment returns the token matched by a pattern segment such as {name}.JSON with the literals in the case of
+// the pattern.
+func canonicalSegment(pattern string, token string) string {
+	node := routetree.NewPatternNode[struct{}](routetree.FoldCase(pattern)).(*routetree.PatternNode[struct{}])
+	values, ok := node.AppendValuesFold(token, nil)
+	if !ok {
+		return token
+	}
+
+	// The pattern is valid since it was registered
+	prefix, parts, _ := tokenizers.ParseSegmentPattern([]byte(pattern))
+
+	var b strings.Builder
+	b.Grow(len(token))
+	b.Write(prefix)
+	for i, part := range parts {
+		b.WriteString(values[i])
+		b.Write(part.Literal)
+	}
+	return b.String()
+}
+
+// Frozen returns true if the host has been frozen.
+func (h *Host[RH, EH]) Frozen() bool {
+	return h.routes.compiled != nil
+}
 
-	resource.HandleMethod(methodUpper, handler)
+// ParamNames returns the names of the host pattern parameters in the order their values are returned by the mux.
+func (h *Host[RH, EH]) ParamNames() []string {
+	return h.params
 }
 
 // ParamMap maps the provided parameter values to their respective names and returns the resulting map.
@@ -155,7 +638,7 @@ func (h *Host[RH, EH]) ParamMap(paramValues []tokenizer.Token) map[string]string
 	paramMap := make(map[string]string, len(h.params))
 
 	for paramIdx, paramName := range h.params {
-		paramMap[string(paramName)] = string(paramValues[paramIdx])
+		paramMap[paramName] = string(paramValues[paramIdx])
 	}
 
 	return paramMap
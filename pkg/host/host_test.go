@@ -0,0 +1,455 @@
+package host_test
+
+import (
+	"slices"
+	"testing"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/predicate"
+)
+
+func TestResource(t *testing.T) {
+	h := host.New[any, any]()
+	h.Handle("GET", "/docs/{id}/revisions/{revision}", nil)
+
+	r, values := h.AppendResource("/docs/123/revisions/456", nil)
+	if r == nil {
+		t.Fatal("Expected resource to match")
+	}
+
+	if len(values) != 2 || values[0] != "123" || values[1] != "456" {
+		t.Errorf("Expected values [123 456], got %v", values)
+	}
+
+	if r, _ := h.AppendResource("/docs//revisions/456", nil); r != nil {
+		t.Error("Expected malformed path to not match")
+	}
+
+	r, tokens := h.Resource([]byte("/docs/123/revisions/456"))
+	if r == nil {
+		t.Fatal("Expected resource to match")
+	}
+
+	if len(tokens) != 2 || tokens[0].String() != "123" || tokens[1].String() != "456" {
+		t.Errorf("Expected tokens [123 456], got %v", tokens)
+	}
+}
+
+func TestResourceMixedSegments(t *testing.T) {
+	h := host.New[any, any]()
+	h.Handle("GET", "/files/{name}.{ext}", nil)
+	h.Handle("GET", "/v{version}/users/@{handle}", nil)
+
+	r, values := h.AppendResource("/files/archive.tar.gz", nil)
+	if r == nil || r.Pattern() != "/files/{name}.{ext}" {
+		t.Fatal("Expected file resource to match")
+	}
+	if names := r.ParamNames("GET"); len(names) != 2 || names[0] != "name" || names[1] != "ext" {
+		t.Errorf("Expected param names [name ext], got %v", names)
+	}
+	if len(values) != 2 || values[0] != "archive.tar" || values[1] != "gz" {
+		t.Errorf("Expected values [archive.tar gz], got %v", values)
+	}
+
+	r, values = h.AppendResource("/v2/users/@proto", nil)
+	if r == nil {
+		t.Fatal("Expected user resource to match")
+	}
+	if names := r.ParamNames("GET"); len(names) != 2 || names[0] != "version" || names[1] != "handle" {
+		t.Errorf("Expected param names [version handle], got %v", names)
+	}
+	if len(values) != 2 || values[0] != "2" || values[1] != "proto" {
+		t.Errorf("Expected values [2 proto], got %v", values)
+	}
+
+	if r, _ := h.AppendResource("/files/readme", nil); r != nil {
+		t.Error("Expected file without extension to not match")
+	}
+}
+
+func TestResourceEscapedBraces(t *testing.T) {
+	h := host.New[any, any]()
+	h.Handle("GET", "/templates/{{default}}", nil)
+	h.Handle("GET", "/templates/{{{name}}}", nil)
+
+	r, values := h.AppendResource("/templates/{default}", nil)
+	if r == nil || r.Pattern() != "/templates/{{default}}" || len(values) != 0 {
+		t.Fatalf("Expected escaped literal to match without values, got %v", values)
+	}
+
+	r, values = h.AppendResource("/templates/{custom}", nil)
+	if r == nil || r.Pattern() != "/templates/{{{name}}}" {
+		t.Fatal("Expected escaped pattern to match")
+	}
+	if len(values) != 1 || values[0] != "custom" {
+		t.Errorf("Expected values [custom], got %v", values)
+	}
+
+	if _, _, err := h.NewResource([]byte("/users/{id}/posts/{id}")); err == nil {
+		t.Error("Expected duplicate label names to be rejected")
+	}
+}
+
+func TestResourceCaseInsensitive(t *testing.T) {
+	h := host.New[any, any]()
+	if err := h.SetCaseInsensitive(true); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	h.Handle("GET", "/Docs/{id}", nil)
+	h.Handle("GET", "/Files/{name}.JSON", nil)
+	h.Handle("GET", "/Reports/{year?}", nil)
+
+	tests := []struct {
+		path      string
+		pattern   string
+		values    []string
+		canonical string
+	}{
+		{"/docs/ABC", "/Docs/{id}", []string{"ABC"}, "/Docs/ABC"},
+		{"/DOCS/abc/", "/Docs/{id}", []string{"abc"}, "/Docs/abc/"},
+		{"/files/Report.json", "/Files/{name}.JSON", []string{"Report"}, "/Files/Report.JSON"},
+		{"/reports", "/Reports", nil, "/Reports"},
+		{"/REPORTS/2024", "/Reports/{year?}", []string{"2024"}, "/Reports/2024"},
+		{"/missing", "", nil, "/missing"},
+	}
+
+	for _, test := range tests {
+		r, values := h.AppendResource(test.path, nil)
+		if test.pattern == "" {
+			if r != nil {
+				t.Errorf("Expected '%s' to not match", test.path)
+			}
+		} else if r == nil || r.Pattern() != test.pattern {
+			t.Errorf("Expected '%s' to match '%s'", test.path, test.pattern)
+		}
+
+		if !slices.Equal(values, test.values) {
+			t.Errorf("Expected values of '%s' to be %v, got %v", test.path, test.values, values)
+		}
+
+		if canonical := h.CanonicalPath(test.path); canonical != test.canonical {
+			t.Errorf("Expected canonical path of '%s' to be '%s', got '%s'", test.path, test.canonical, canonical)
+		}
+	}
+
+	h.Freeze()
+	if r, values := h.AppendResource("/docs/ABC", nil); r == nil || !slices.Equal(values, []string{"ABC"}) {
+		t.Errorf("Expected frozen host to match case-insensitively, got %v", values)
+	}
+
+	if err := h.SetCaseInsensitive(false); err != host.ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+
+	sensitive := host.New[any, any]()
+	sensitive.Handle("GET", "/Docs", nil)
+	if err := sensitive.SetCaseInsensitive(true); err != host.ErrRoutesRegistered {
+		t.Errorf("Expected ErrRoutesRegistered, got %v", err)
+	}
+	if r, _ := sensitive.AppendResource("/docs", nil); r != nil {
+		t.Error("Expected case-sensitive host to not match a different case")
+	}
+	if canonical := sensitive.CanonicalPath("/docs"); canonical != "/docs" {
+		t.Errorf("Expected case-sensitive host to not change the path, got '%s'", canonical)
+	}
+}
+
+func TestResourceNormalization(t *testing.T) {
+	nfc := "caf\u00e9"
+	nfd := "cafe\u0301"
+
+	h := host.New[any, any]()
+	if err := h.SetNormalization(true, norm.NFC); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	h.Handle("GET", "/"+nfd+"/{name}", nil)
+	h.Handle("GET", "/menu/{item=cr\u00e8me}", nil)
+
+	for _, path := range []string{"/" + nfc + "/" + nfd, "/" + nfd + "/" + nfc} {
+		r, values := h.AppendResource(path, nil)
+		if r == nil {
+			t.Errorf("Expected '%+q' to match", path)
+			continue
+		}
+		if len(values) != 1 || values[0] != nfc {
+			t.Errorf("Expected values of '%+q' to be normalized, got %+q", path, values)
+		}
+	}
+
+	r, _ := h.AppendResource("/menu", nil)
+	if defaults := r.ParamDefaults("GET"); len(defaults) != 1 || defaults[0] != "cr\u00e8me" {
+		t.Errorf("Expected normalized default, got %+q", defaults)
+	}
+
+	h.Freeze()
+	if r, values := h.AppendResource("/"+nfc+"/"+nfd, nil); r == nil || len(values) != 1 || values[0] != nfc {
+		t.Errorf("Expected frozen host to normalize paths, got %+q", values)
+	}
+
+	values := make([]string, 0, 1)
+	if allocs := testing.AllocsPerRun(100, func() {
+		h.AppendResource("/menu/soup", values)
+	}); allocs != 0 {
+		t.Errorf("Expected normalized lookups to not allocate, got %v allocations", allocs)
+	}
+
+	plain := host.New[any, any]()
+	plain.Handle("GET", "/"+nfc, nil)
+	if r, _ := plain.AppendResource("/"+nfd, nil); r != nil {
+		t.Error("Expected host without normalization to compare raw bytes")
+	}
+	if err := plain.SetNormalization(true, norm.NFC); err != host.ErrRoutesRegistered {
+		t.Errorf("Expected ErrRoutesRegistered, got %v", err)
+	}
+}
+
+func TestResourceOptionalSegments(t *testing.T) {
+	h := host.New[any, any]()
+	h.Handle("GET", "/reports/{year?}/{month=01}", nil)
+
+	tests := []struct {
+		path     string
+		pattern  string
+		names    []string
+		values   []string
+		defaults []string
+	}{
+		{"/reports", "/reports", []string{"month"}, nil, []string{"01"}},
+		{"/reports/2024", "/reports/{year?}", []string{"year", "month"}, []string{"2024"}, []string{"01"}},
+		{"/reports/2024/06", "/reports/{year?}/{month=01}", []string{"year", "month"}, []string{"2024", "06"}, nil},
+	}
+
+	for _, test := range tests {
+		r, values := h.AppendResource(test.path, nil)
+		if r == nil {
+			t.Errorf("Expected '%s' to match", test.path)
+			continue
+		}
+
+		if r.Pattern() != test.pattern {
+			t.Errorf("Expected pattern of '%s' to be '%s', got '%s'", test.path, test.pattern, r.Pattern())
+		}
+
+		if !slices.Equal(r.ParamNames("GET"), test.names) || !slices.Equal(values, test.values) || !slices.Equal(r.ParamDefaults("GET"), test.defaults) {
+			t.Errorf("Expected '%s' to have names %v, values %v and defaults %v, got %v, %v and %v", test.path, test.names, test.values, test.defaults, r.ParamNames("GET"), values, r.ParamDefaults("GET"))
+		}
+
+		_, tokens := h.Resource([]byte(test.path))
+		if params := r.ParamMap("GET", tokens); params["month"] == "" {
+			t.Errorf("Expected '%s' to have a month parameter, got %v", test.path, params)
+		}
+	}
+
+	if r, _ := h.AppendResource("/reports/2024/06/01", nil); r != nil {
+		t.Error("Expected longer path to not match")
+	}
+}
+
+func TestGroupResourceOptionalSegments(t *testing.T) {
+	h := host.New[any, any]()
+	g := h.Group("/api")
+
+	if _, _, err := g.NewResource([]byte("/reports/{year?}")); err != nil {
+		t.Fatalf("Unexpected error creating resource: %s", err)
+	}
+
+	for _, path := range []string{"/api/reports", "/api/reports/2024"} {
+		r, _ := h.AppendResource(path, nil)
+		if r == nil {
+			t.Errorf("Expected '%s' to match", path)
+			continue
+		}
+
+		if group := h.ResourceGroup(r); group != g {
+			t.Errorf("Expected resource for '%s' to belong to the group", path)
+		}
+	}
+}
+
+func TestResourceParamConflicts(t *testing.T) {
+	expectPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected %s to panic", name)
+			}
+		}()
+		fn()
+	}
+
+	v2 := predicate.Header("Accept-Version", "2")
+
+	h := host.New[any, any]()
+	h.Handle("GET", "/a", nil)
+	expectPanic("default for a path without parameters", func() {
+		h.HandleWhen("GET", "/a/{x=1}", v2, 0, nil)
+	})
+
+	if r, _ := h.AppendResource("/a", nil); r == nil || r.ParamNames("GET") != nil || r.ParamDefaults("GET") != nil {
+		t.Error("Expected plain handler to keep having no parameters")
+	}
+
+	h.Handle("GET", "/docs/{id}", nil)
+	expectPanic("different label name", func() {
+		h.HandleWhen("GET", "/docs/{name}", v2, 0, nil)
+	})
+
+	h.Handle("GET", "/reports/{month=01}", nil)
+	expectPanic("different default", func() {
+		h.HandleWhen("GET", "/reports/{month=02}", v2, 0, nil)
+	})
+
+	// Handlers for the same parameters and other methods with other names are allowed
+	h.HandleWhen("GET", "/docs/{id}", v2, 0, nil)
+	h.Handle("POST", "/docs/{name}", nil)
+	h.HandleWhen("GET", "/reports/{month=01}", v2, 0, nil)
+}
+
+func TestResourceOptionalSegmentErrors(t *testing.T) {
+	h := host.New[any, any]()
+
+	for _, pattern := range []string{"/reports/{year?}/summary", "/files/{name?}.{ext}"} {
+		if _, _, err := h.NewResource([]byte(pattern)); err != host.ErrOptionalSegment {
+			t.Errorf("Expected ErrOptionalSegment for '%s', got %v", pattern, err)
+		}
+	}
+}
+
+func TestResourceAllocations(t *testing.T) {
+	h := host.New[any, any]()
+	h.Handle("GET", "/api/v1/internal/admin/reports", nil)
+	h.Handle("GET", "/docs/{id}/revisions/{revision}", nil)
+
+	buf := make([]string, 0, 4)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if r, _ := h.AppendResource("/api/v1/internal/admin/reports", buf[:0]); r == nil {
+			t.Error("Expected static resource to match")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected static resource lookup to not allocate, got %f allocations", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(100, func() {
+		if r, _ := h.AppendResource("/docs/123/revisions/456", buf[:0]); r == nil {
+			t.Error("Expected parameterized resource to match")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected parameterized resource lookup with a buffer to not allocate, got %f allocations", allocs)
+	}
+}
+
+func TestNewShared(t *testing.T) {
+	template := host.New[any, any]()
+	template.Handle("GET", "/docs/{id}", nil)
+
+	h := host.NewShared(template, "a.example.com", nil)
+
+	if !h.SharesRoutes(template) || !template.SharesRoutes(h) {
+		t.Error("Expected hosts to share routes")
+	}
+
+	if h.SharesRoutes(host.New[any, any]()) {
+		t.Error("Expected new host to not share routes")
+	}
+
+	if r, values := h.AppendResource("/docs/123", nil); r == nil || len(values) != 1 || values[0] != "123" {
+		t.Errorf("Expected shared host to find resource with value '123', got %v", values)
+	}
+
+	if _, _, err := h.NewResource([]byte("/other")); err != host.ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+
+	r, _ := h.AppendResource("/docs/123", nil)
+	if err := h.SetResourceErrorHandler(r, "a"); err != host.ErrFrozen {
+		t.Errorf("Expected ErrFrozen setting a resource error handler on a shared host, got %v", err)
+	}
+
+	if _, ok := template.ResourceErrorHandler(r); ok {
+		t.Error("Expected the shared routes to not have a resource error handler")
+	}
+}
+
+func BenchmarkResource_Static(b *testing.B) {
+	h := host.New[any, any]()
+	h.Handle("GET", "/api/v1/internal/admin/reports", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if r, _ := h.AppendResource("/api/v1/internal/admin/reports", nil); r == nil {
+			b.Error("got nil resource")
+			return
+		}
+	}
+}
+
+func BenchmarkResource_Params(b *testing.B) {
+	h := host.New[any, any]()
+	h.Handle("GET", "/docs/{id}/revisions/{revision}", nil)
+
+	buf := make([]string, 0, 4)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if r, _ := h.AppendResource("/docs/123/revisions/456", buf[:0]); r == nil {
+			b.Error("got nil resource")
+			return
+		}
+	}
+}
+
+func TestLocales(t *testing.T) {
+	locales := host.NewLocales(language.English, language.French, language.MustParse("pt-BR"))
+
+	tests := []struct {
+		path   string
+		locale language.Tag
+		rest   string
+		ok     bool
+	}{
+		{"/en/docs", language.English, "/docs", true},
+		{"/FR/docs/", language.French, "/docs/", true},
+		{"/pt-br", language.MustParse("pt-BR"), "/", true},
+		{"/en/", language.English, "/", true},
+		{"/english/docs", language.Und, "/english/docs", false},
+		{"/docs", language.Und, "/docs", false},
+		{"/", language.Und, "/", false},
+	}
+
+	for _, test := range tests {
+		locale, rest, ok := locales.Split(test.path)
+		if locale != test.locale || rest != test.rest || ok != test.ok {
+			t.Errorf("Expected '%s' to split into %s '%s' %t, got %s '%s' %t", test.path, test.locale, test.rest, test.ok, locale, rest, ok)
+		}
+	}
+
+	matches := map[string]language.Tag{
+		"fr-CH, fr;q=0.9, en;q=0.8": language.French,
+		"pt":                        language.MustParse("pt-BR"),
+		"de":                        language.English,
+		"":                          language.English,
+		"not a language;q=x":        language.English,
+	}
+
+	for header, expected := range matches {
+		if locale := locales.Match(header); locale != expected {
+			t.Errorf("Expected Accept-Language '%s' to match %s, got %s", header, expected, locale)
+		}
+	}
+
+	if prefix := locales.Prefix(language.MustParse("pt-BR")); prefix != "/pt-BR" {
+		t.Errorf("Expected prefix '/pt-BR', got '%s'", prefix)
+	}
+	if prefix := locales.Prefix(language.German); prefix != "" {
+		t.Errorf("Expected no prefix for an unsupported language, got '%s'", prefix)
+	}
+}
@@ -0,0 +1,87 @@
+package host
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Locales describes the languages a host serves its pages in. Every route of a host with locales is served under a
+// /{lang} prefix for each of the languages, e.g. a route registered as /docs is served as /en/docs and /fr/docs.
+// Routes are registered without the prefix.
+//
+// Locales hold no state after they are created and may be shared by many hosts.
+type Locales struct {
+	tags     []language.Tag
+	prefixes []string // prefixes are the path prefixes of the tags, e.g. "/en-US".
+	matcher  language.Matcher
+}
+
+// NewLocales creates locales for the supported languages. The first language is the default used when the
+// Accept-Language header of a request does not match any of them.
+// It panics if there are no languages.
+func NewLocales(tags ...language.Tag) *Locales {
+	if len(tags) == 0 {
+		panic("expected at least one language")
+	}
+
+	l := &Locales{
+		tags:     tags,
+		prefixes: make([]string, len(tags)),
+		matcher:  language.NewMatcher(tags),
+	}
+	for i, tag := range tags {
+		l.prefixes[i] = "/" + tag.String()
+	}
+	return l
+}
+
+// Tags returns the supported languages in the order they were given.
+func (l *Locales) Tags() []language.Tag {
+	return l.tags
+}
+
+// Split removes the language prefix from a request path and returns the language and the rest of the path.
+// Prefixes are matched ignoring case. The rest of a path that is only a prefix, such as /en, is "/".
+//
+// If the path does not start with the prefix of a supported language false is returned.
+func (l *Locales) Split(path string) (language.Tag, string, bool) {
+	if len(path) == 0 || path[0] != '/' {
+		return language.Und, path, false
+	}
+
+	segment := path[1:]
+	if end := strings.IndexByte(segment, '/'); end >= 0 {
+		segment = segment[:end]
+	}
+
+	for i, prefix := range l.prefixes {
+		if strings.EqualFold(segment, prefix[1:]) {
+			rest := path[1+len(segment):]
+			if rest == "" {
+				rest = "/"
+			}
+			return l.tags[i], rest, true
+		}
+	}
+
+	return language.Und, path, false
+}
+
+// Match returns the supported language that best matches the value of an Accept-Language header.
+// The default language is returned if none match or the header is empty or malformed.
+func (l *Locales) Match(acceptLanguage string) language.Tag {
+	_, index := language.MatchStrings(l.matcher, acceptLanguage)
+	return l.tags[index]
+}
+
+// Prefix returns the path prefix of a supported language, e.g. "/en-US". It returns an empty string if the language
+// is not supported.
+func (l *Locales) Prefix(tag language.Tag) string {
+	for i, supported := range l.tags {
+		if supported == tag {
+			return l.prefixes[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package host
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy describes the redirects and security headers that are applied to requests for a host before they are
+// routed. Policies hold no state and may be shared by many hosts.
+type Policy struct {
+	CanonicalHost         string        // CanonicalHost is the hostname other hostnames of the host are redirected to, e.g. "example.com". Empty disables the redirect.
+	RequireHTTPS          bool          // RequireHTTPS redirects requests that were not made over HTTPS to HTTPS.
+	RedirectStatus        int           // RedirectStatus is the status code of redirects, 301 or 308. Zero uses 301 for GET and HEAD requests and 308 otherwise.
+	HSTSMaxAge            time.Duration // HSTSMaxAge adds a Strict-Transport-Security header to HTTPS responses. Zero disables the header.
+	HSTSIncludeSubdomains bool          // HSTSIncludeSubdomains adds the includeSubDomains directive to the Strict-Transport-Security header.
+	HSTSPreload           bool          // HSTSPreload adds the preload directive to the Strict-Transport-Security header.
+	RedirectPathCase      bool          // RedirectPathCase redirects requests to case-insensitive hosts to the path with the casing of the pattern it matches, see Host.CanonicalPath.
+}
+
+// HSTSHeader returns the value of the Strict-Transport-Security header or an empty string if HSTS is disabled.
+func (p *Policy) HSTSHeader() string {
+	if p.HSTSMaxAge <= 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("max-age=")
+	b.WriteString(strconv.FormatInt(int64(p.HSTSMaxAge/time.Second), 10))
+
+	if p.HSTSIncludeSubdomains {
+		b.WriteString("; includeSubDomains")
+	}
+	if p.HSTSPreload {
+		b.WriteString("; preload")
+	}
+
+	return b.String()
+}
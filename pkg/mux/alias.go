@@ -0,0 +1,109 @@
+package mux
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+// hostAlias is an exact hostname that is served by another host with fixed parameter values.
+type hostAlias[RH any, EH any] struct {
+	host   *host.Host[RH, EH]
+	values []string // values are the parameter values in the order of the parameter names of the host.
+}
+
+// hostAliases holds the aliases of a mux.
+//
+// Lookups read an immutable map without locking. Updates copy the map so they are safe while the mux is serving
+// requests but cost time proportional to the number of aliases.
+type hostAliases[RH any, EH any] struct {
+	mu      sync.Mutex
+	aliases atomic.Pointer[map[string]hostAlias[RH, EH]]
+}
+
+// get returns the alias for the key of a hostname, see hostKey, and whether it exists.
+func (a *hostAliases[RH, EH]) get(key string) (hostAlias[RH, EH], bool) {
+	aliases := a.aliases.Load()
+	if aliases == nil {
+		return hostAlias[RH, EH]{}, false
+	}
+
+	alias, ok := (*aliases)[key]
+	return alias, ok
+}
+
+// update replaces the aliases with a copy that was modified by the update function.
+func (a *hostAliases[RH, EH]) update(update func(aliases map[string]hostAlias[RH, EH])) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	aliases := make(map[string]hostAlias[RH, EH])
+	if existing := a.aliases.Load(); existing != nil {
+		for hostname, alias := range *existing {
+			aliases[hostname] = alias
+		}
+	}
+
+	update(aliases)
+	a.aliases.Store(&aliases)
+}
+
+// SetAlias makes an exact hostname, such as a custom domain of a tenant, an alias of an existing host.
+//
+// Requests for the hostname are served by the target host as if its pattern had matched, with the host parameters
+// set to params. Params must contain a value for each parameter of the target host pattern and nothing else.
+// Aliases take precedence over registered hosts and match the hostname on any port and in any casing. Setting an
+// alias that already exists replaces it.
+//
+// Aliases can be set and removed at any time, including after freezing and while the mux is serving requests.
+func (m *Mux[RH, EH]) SetAlias(hostname string, target *host.Host[RH, EH], params map[string]string) error {
+	if target == nil {
+		panic("expected host to not be nil")
+	}
+
+	names := target.ParamNames()
+	if len(params) != len(names) {
+		return fmt.Errorf("alias for %s has %d parameter(s), host %s expects %d", hostname, len(params), target.Pattern(), len(names))
+	}
+
+	var values []string
+	if len(names) > 0 {
+		values = make([]string, len(names))
+		for i, name := range names {
+			value, ok := params[name]
+			if !ok {
+				return fmt.Errorf("alias for %s is missing parameter %s of host %s", hostname, name, target.Pattern())
+			}
+			values[i] = value
+		}
+	}
+
+	m.aliases.update(func(aliases map[string]hostAlias[RH, EH]) {
+		aliases[hostKey(hostname)] = hostAlias[RH, EH]{
+			host:   target,
+			values: values,
+		}
+	})
+	return nil
+}
+
+// RemoveAlias removes the alias for the hostname if it exists.
+func (m *Mux[RH, EH]) RemoveAlias(hostname string) {
+	m.aliases.update(func(aliases map[string]hostAlias[RH, EH]) {
+		delete(aliases, hostKey(hostname))
+	})
+}
+
+// Alias returns the host and the parameters of the alias for the hostname, or nil if there is no alias.
+func (m *Mux[RH, EH]) Alias(hostname string) (*host.Host[RH, EH], map[string]string) {
+	alias, ok := m.aliases.get(hostKey(hostname))
+	if !ok {
+		return nil, nil
+	}
+
+	params := muxcontext.NewParams(alias.host.ParamNames(), alias.values)
+	return alias.host, params.Map()
+}
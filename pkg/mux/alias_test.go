@@ -0,0 +1,85 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+func TestAlias(t *testing.T) {
+	m := mux.NewHTTP()
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.Handle(http.MethodGet, "/docs/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(muxcontext.HostParam(r.Context(), "db") + ":" + muxcontext.PathParam(r.Context(), "id")))
+	}))
+
+	m.Freeze()
+
+	if err := m.SetAlias("docs.customer.com", h, map[string]string{"db": "customer"}); err != nil {
+		t.Fatalf("Unexpected error setting alias: %s", err)
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://docs.customer.com/docs/123", nil))
+
+	if w.Body.String() != "customer:123" {
+		t.Errorf("Expected alias to serve 'customer:123', got %d '%s'", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://Docs.Customer.com:8443/docs/123", nil))
+
+	if w.Body.String() != "customer:123" {
+		t.Errorf("Expected alias to ignore the port and casing, got %d '%s'", w.Code, w.Body.String())
+	}
+
+	if target, params := m.Alias("docs.customer.com"); target != h || params["db"] != "customer" {
+		t.Errorf("Expected alias to be returned, got %v", params)
+	}
+
+	m.RemoveAlias("DOCS.customer.com")
+
+	if found, _ := m.Host("docs.customer.com"); found != m.DefaultHost() {
+		t.Error("Expected removed alias to not match")
+	}
+
+	if target, _ := m.Alias("docs.customer.com"); target != nil {
+		t.Error("Expected removed alias to not be returned")
+	}
+}
+
+func TestAliasParams(t *testing.T) {
+	m := mux.New[any, any]()
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	if err := m.SetAlias("docs.customer.com", h, nil); err == nil {
+		t.Error("Expected error for missing parameters")
+	}
+
+	if err := m.SetAlias("docs.customer.com", h, map[string]string{"other": "value"}); err == nil {
+		t.Error("Expected error for unknown parameter")
+	}
+
+	if err := m.SetAlias("docs.customer.com", h, map[string]string{"db": "a", "other": "value"}); err == nil {
+		t.Error("Expected error for extra parameter")
+	}
+
+	if err := m.SetAlias("test.example.com", h, map[string]string{"db": "aliased"}); err != nil {
+		t.Fatalf("Unexpected error setting alias: %s", err)
+	}
+
+	if found, values := m.AppendHost("test.example.com", nil); found != h || len(values) != 1 || values[0] != "aliased" {
+		t.Errorf("Expected alias to take precedence over the host pattern, got %v", values)
+	}
+}
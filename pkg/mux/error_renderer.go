@@ -0,0 +1,188 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"proto.zip/studio/mux/internal/negotiate"
+	"proto.zip/studio/validate/pkg/errors"
+)
+
+// Media types supported by ErrorRenderer.
+const (
+	MediaTypeProblemJSON = "application/problem+json"
+	MediaTypeHTML        = "text/html"
+	MediaTypePlainText   = "text/plain"
+)
+
+// Problem is an RFC 9457 problem details object.
+type Problem struct {
+	Type     string         `json:"type,omitempty"`     // A URI reference that identifies the problem type.
+	Title    string         `json:"title"`              // A short, human-readable summary of the problem type.
+	Status   int            `json:"status"`             // The HTTP status code.
+	Detail   string         `json:"detail,omitempty"`   // A human-readable explanation specific to this occurrence.
+	Instance string         `json:"instance,omitempty"` // A URI reference that identifies the specific occurrence.
+	Errors   []ProblemField `json:"errors,omitempty"`   // Field level validation errors, if any.
+}
+
+// ProblemField is a field level entry in the "errors" extension member of a Problem.
+type ProblemField struct {
+	Path   string `json:"path,omitempty"` // The path of the field that failed validation.
+	Code   string `json:"code,omitempty"` // A machine readable error code.
+	Detail string `json:"detail"`         // A human-readable description of the error.
+}
+
+// ErrorPage is the data passed to HTML error templates.
+type ErrorPage struct {
+	Status  int           // The HTTP status code.
+	Title   string        // The status text.
+	Problem Problem       // The problem details for the error.
+	Err     error         // The original error.
+	Request *http.Request // The request that caused the error.
+}
+
+// ErrorRenderer renders errors in the representation that best matches the Accept header of the request.
+//
+// Assign the ServeError method to the ErrorHandler of a host to enable it:
+//
+//	h.ErrorHandler = (&mux.ErrorRenderer{ProblemJSON: true}).ServeError
+//
+// The offered representations are, in order of preference: application/problem+json if ProblemJSON is true,
+// text/html if HTMLTemplate is set and text/plain which is always available.
+// The first offered representation is used if the request does not have an Accept header or nothing matches.
+type ErrorRenderer struct {
+	// ProblemJSON enables RFC 9457 application/problem+json responses.
+	ProblemJSON bool
+
+	// ProblemTypeBase is prepended to the status code to build the problem type URI.
+	// If empty, the type member is omitted which is equivalent to "about:blank".
+	ProblemTypeBase string
+
+	// HTMLTemplate enables text/html responses. The template is executed with an ErrorPage.
+	// If the template set contains a template named after the status code (e.g. "404") that template is used instead.
+	HTMLTemplate *template.Template
+}
+
+// offers returns the media types this renderer can produce in order of preference.
+func (er *ErrorRenderer) offers() []string {
+	offers := make([]string, 0, 3)
+	if er.ProblemJSON {
+		offers = append(offers, MediaTypeProblemJSON)
+	}
+	if er.HTMLTemplate != nil {
+		offers = append(offers, MediaTypeHTML)
+	}
+	return append(offers, MediaTypePlainText)
+}
+
+// Problem builds the problem details for an error.
+func (er *ErrorRenderer) Problem(err error, r *http.Request) Problem {
+	status := ErrorStatusCode(err)
+
+	p := Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+
+	if er.ProblemTypeBase != "" {
+		p.Type = er.ProblemTypeBase + strconv.Itoa(status)
+	}
+
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.RequestURI()
+	}
+
+	if validationErr, ok := err.(errors.ValidationError); ok {
+		p.Detail = validationErr.Error()
+		p.Errors = problemFields(validationErr)
+	}
+
+	return p
+}
+
+// ServeError implements HttpErrorHandler.
+func (er *ErrorRenderer) ServeError(err error, w http.ResponseWriter, r *http.Request) {
+	p := er.Problem(err, r)
+
+	if p.Status == http.StatusInternalServerError {
+		if _, ok := err.(HttpError); !ok {
+			logUnhandledError(err, r)
+		}
+	}
+
+	mediaType, _ := negotiate.Best(r.Header.Get("Accept"), er.offers())
+	if mediaType == "" {
+		mediaType = MediaTypePlainText
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	switch mediaType {
+	case MediaTypeProblemJSON:
+		body, jsonErr := json.Marshal(p)
+		if jsonErr != nil {
+			break
+		}
+		w.Header().Set("Content-Type", MediaTypeProblemJSON)
+		w.WriteHeader(p.Status)
+		w.Write(body)
+		return
+	case MediaTypeHTML:
+		tmpl := er.HTMLTemplate
+		if named := tmpl.Lookup(strconv.Itoa(p.Status)); named != nil {
+			tmpl = named
+		}
+
+		page := ErrorPage{
+			Status:  p.Status,
+			Title:   p.Title,
+			Problem: p,
+			Err:     err,
+			Request: r,
+		}
+
+		// Render into a buffer so a failing template falls back to plain text instead of a truncated page.
+		var body bytes.Buffer
+		if tmplErr := tmpl.Execute(&body, page); tmplErr != nil {
+			logUnhandledError(tmplErr, r)
+			break
+		}
+
+		w.Header().Set("Content-Type", MediaTypeHTML+"; charset=utf-8")
+		w.WriteHeader(p.Status)
+		w.Write(body.Bytes())
+		return
+	}
+
+	w.Header().Set("Content-Type", MediaTypePlainText+"; charset=utf-8")
+	w.WriteHeader(p.Status)
+	w.Write([]byte(p.Title))
+}
+
+// problemFields flattens a validation error into field level problem entries.
+// Validation error collections produce one entry per error in the collection.
+func problemFields(err errors.ValidationError) []ProblemField {
+	collection, ok := err.(errors.ValidationErrorCollection)
+	if !ok {
+		return []ProblemField{problemField(err)}
+	}
+
+	all := collection.All()
+	fields := make([]ProblemField, 0, len(all))
+	for _, child := range all {
+		fields = append(fields, problemField(child))
+	}
+	return fields
+}
+
+// problemField converts a single validation error into a problem entry.
+func problemField(err errors.ValidationError) ProblemField {
+	return ProblemField{
+		Path:   err.Path(),
+		Code:   err.Code(),
+		Detail: err.ShortError(),
+	}
+}
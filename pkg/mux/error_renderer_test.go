@@ -0,0 +1,219 @@
+package mux_test
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/validate/pkg/errors"
+	"proto.zip/studio/validate/pkg/rulecontext"
+)
+
+func newErrorRendererMux(t *testing.T, renderer *mux.ErrorRenderer) *mux.HttpMux {
+	t.Helper()
+
+	m := mux.NewHTTP()
+	h, err := m.NewHost("api.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.ErrorHandler = renderer.ServeError
+	h.Handle(http.MethodGet, "/docs/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	return m
+}
+
+func TestErrorRendererProblemJSON(t *testing.T) {
+	m := newErrorRendererMux(t, &mux.ErrorRenderer{
+		ProblemJSON:     true,
+		ProblemTypeBase: "https://example.com/problems/",
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/missing", nil)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != mux.MediaTypeProblemJSON {
+		t.Errorf("Expected problem content type, got '%s'", ct)
+	}
+
+	var p mux.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Unexpected error decoding body: %s", err)
+	}
+
+	if p.Status != http.StatusNotFound || p.Title != http.StatusText(http.StatusNotFound) {
+		t.Errorf("Unexpected problem: %+v", p)
+	}
+
+	if p.Type != "https://example.com/problems/404" {
+		t.Errorf("Unexpected problem type '%s'", p.Type)
+	}
+
+	if p.Instance != "/missing" {
+		t.Errorf("Unexpected problem instance '%s'", p.Instance)
+	}
+}
+
+func TestErrorRendererHTML(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`generic {{.Status}}`))
+	template.Must(tmpl.New("405").Parse(`not allowed {{.Title}}`))
+
+	m := newErrorRendererMux(t, &mux.ErrorRenderer{
+		ProblemJSON:  true,
+		HTMLTemplate: tmpl,
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/missing", nil)
+	r.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), mux.MediaTypeHTML) {
+		t.Errorf("Expected HTML content type, got '%s'", w.Header().Get("Content-Type"))
+	}
+
+	if body := w.Body.String(); body != "generic 404" {
+		t.Errorf("Expected generic template, got '%s'", body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "http://api.example.com/docs/1", nil)
+	r.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if body := w.Body.String(); body != "not allowed Method Not Allowed" {
+		t.Errorf("Expected status specific template, got '%s'", body)
+	}
+}
+
+func TestErrorRendererPlainText(t *testing.T) {
+	m := newErrorRendererMux(t, &mux.ErrorRenderer{
+		ProblemJSON: true,
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/missing", nil)
+	r.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), mux.MediaTypePlainText) {
+		t.Errorf("Expected plain text content type, got '%s'", w.Header().Get("Content-Type"))
+	}
+
+	if body := w.Body.String(); body != http.StatusText(http.StatusNotFound) {
+		t.Errorf("Unexpected body '%s'", body)
+	}
+
+	if vary := w.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("Expected Vary header to be 'Accept', got '%s'", vary)
+	}
+}
+
+func TestErrorRendererHTMLTemplateError(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`partial {{.Missing}}`))
+
+	m := newErrorRendererMux(t, &mux.ErrorRenderer{
+		HTMLTemplate: tmpl,
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/missing", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), mux.MediaTypePlainText) {
+		t.Errorf("Expected plain text content type, got '%s'", w.Header().Get("Content-Type"))
+	}
+
+	if body := w.Body.String(); body != http.StatusText(http.StatusNotFound) {
+		t.Errorf("Expected plain text fallback, got '%s'", body)
+	}
+}
+
+// serveProblem serves err with a problem+json renderer and decodes the response.
+func serveProblem(t *testing.T, err error) mux.Problem {
+	t.Helper()
+
+	renderer := &mux.ErrorRenderer{ProblemJSON: true}
+
+	r := httptest.NewRequest(http.MethodPost, "http://api.example.com/docs", nil)
+	w := httptest.NewRecorder()
+	renderer.ServeError(err, w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	var p mux.Problem
+	if jsonErr := json.Unmarshal(w.Body.Bytes(), &p); jsonErr != nil {
+		t.Fatalf("Unexpected error decoding body: %s", jsonErr)
+	}
+
+	if p.Status != http.StatusBadRequest {
+		t.Errorf("Expected problem status 400, got %d", p.Status)
+	}
+
+	return p
+}
+
+func TestErrorRendererValidationError(t *testing.T) {
+	ctx := rulecontext.WithPathString(context.Background(), "name")
+	err := errors.Errorf(errors.CodeRequired, ctx, "field is required", "name is required")
+
+	p := serveProblem(t, err)
+
+	if p.Detail != err.Error() {
+		t.Errorf("Expected detail '%s', got '%s'", err.Error(), p.Detail)
+	}
+
+	if len(p.Errors) != 1 {
+		t.Fatalf("Expected 1 field error, got %d", len(p.Errors))
+	}
+
+	expected := mux.ProblemField{Path: err.Path(), Code: errors.CodeRequired, Detail: "field is required"}
+	if p.Errors[0] != expected {
+		t.Errorf("Expected field error %+v, got %+v", expected, p.Errors[0])
+	}
+}
+
+func TestErrorRendererValidationErrorCollection(t *testing.T) {
+	ctx := context.Background()
+	nameErr := errors.Errorf(errors.CodeRequired, rulecontext.WithPathString(ctx, "name"), "field is required", "name is required")
+	ageErr := errors.Errorf(errors.CodeMin, rulecontext.WithPathString(ctx, "age"), "value is too small", "age must be at least 18")
+
+	p := serveProblem(t, errors.Collection(nameErr, ageErr))
+
+	if len(p.Errors) != 2 {
+		t.Fatalf("Expected 2 field errors, got %d", len(p.Errors))
+	}
+
+	expected := map[string]mux.ProblemField{
+		nameErr.Path(): {Path: nameErr.Path(), Code: errors.CodeRequired, Detail: "field is required"},
+		ageErr.Path():  {Path: ageErr.Path(), Code: errors.CodeMin, Detail: "value is too small"},
+	}
+
+	for _, field := range p.Errors {
+		if field != expected[field.Path] {
+			t.Errorf("Expected field error %+v, got %+v", expected[field.Path], field)
+		}
+		delete(expected, field.Path)
+	}
+
+	if len(expected) != 0 {
+		t.Errorf("Missing field errors: %+v", expected)
+	}
+}
@@ -0,0 +1,205 @@
+package mux
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+// ForwardedHeaders selects the headers that the trusted proxies of a HttpMux use to report the original client
+// request. Only the selected headers are read, the others are passed through by most proxies unchanged and may have
+// been sent by the client.
+type ForwardedHeaders int
+
+const (
+	ForwardedHeadersX       ForwardedHeaders = iota // ForwardedHeadersX reads X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto.
+	ForwardedHeadersRFC7239                         // ForwardedHeadersRFC7239 reads the RFC 7239 Forwarded header.
+)
+
+// trustedPeer returns true if the request was received from one of the trusted proxies.
+func (m *HttpMux) trustedPeer(r *http.Request) bool {
+	var addr netip.Addr
+	if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		addr = addrPort.Addr()
+	} else if addr, err = netip.ParseAddr(r.RemoteAddr); err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range m.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList calls fn for each part of s separated by sep. Separators inside quoted strings are ignored.
+func splitList(s string, sep byte, fn func(part string)) {
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			fn(strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	fn(strings.TrimSpace(s[start:]))
+}
+
+// lastHeaderElement returns the last element of a comma separated header that may be sent multiple times.
+//
+// Proxies append to these headers so the last element is the one added by the proxy closest to the mux. Earlier
+// elements may have been sent by the client and cannot be trusted.
+func lastHeaderElement(h http.Header, name string) string {
+	values := h.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+
+	var last string
+	splitList(values[len(values)-1], ',', func(part string) {
+		last = part
+	})
+	return last
+}
+
+// unquote removes the quotes and escapes from an RFC 7230 quoted string. Other values are returned unchanged.
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+
+	value = value[1 : len(value)-1]
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// validHostPort returns true if hostport is a hostname, an IPv4 address or a bracketed IPv6 address, optionally
+// followed by a port.
+func validHostPort(hostport string) bool {
+	host := hostport
+	if strings.LastIndexByte(hostport, ':') > strings.LastIndexByte(hostport, ']') {
+		var port string
+		var err error
+		if host, port, err = net.SplitHostPort(hostport); err != nil || !validPort(port) {
+			return false
+		}
+	} else if strings.HasPrefix(hostport, "[") && strings.HasSuffix(hostport, "]") {
+		host = hostport[1 : len(hostport)-1]
+	} else if strings.ContainsAny(hostport, "[]") {
+		return false
+	}
+
+	// Only IPv6 addresses have colons and they must have been bracketed
+	if strings.IndexByte(host, ':') >= 0 || strings.HasPrefix(hostport, "[") {
+		addr, err := netip.ParseAddr(host)
+		return err == nil && addr.Is6()
+	}
+
+	return validHostname(host)
+}
+
+// validPort returns true if port is a decimal port number.
+func validPort(port string) bool {
+	if len(port) == 0 || len(port) > 5 {
+		return false
+	}
+	for i := 0; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validHostname returns true if hostname is a sequence of dot separated labels of letters, digits, hyphens and
+// underscores. A trailing dot is allowed.
+func validHostname(hostname string) bool {
+	hostname = strings.TrimSuffix(hostname, ".")
+	if len(hostname) == 0 || len(hostname) > 253 {
+		return false
+	}
+
+	labelLen := 0
+	for i := 0; i < len(hostname); i++ {
+		c := hostname[i]
+		switch {
+		case c == '.':
+			if labelLen == 0 {
+				return false
+			}
+			labelLen = 0
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+			labelLen++
+			if labelLen > 63 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return labelLen > 0
+}
+
+// forwardedRequest reads the original client request values from the headers selected by headers. The path prefix
+// is always read from X-Forwarded-Prefix. Hosts that are not a hostname with an optional port are ignored.
+func forwardedRequest(r *http.Request, headers ForwardedHeaders) muxcontext.ForwardedRequest {
+	var fr muxcontext.ForwardedRequest
+
+	switch headers {
+	case ForwardedHeadersRFC7239:
+		element := lastHeaderElement(r.Header, "Forwarded")
+		splitList(element, ';', func(pair string) {
+			key, value, _ := strings.Cut(pair, "=")
+			value = unquote(strings.TrimSpace(value))
+
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "for":
+				fr.For = value
+			case "host":
+				fr.Host = value
+			case "proto":
+				fr.Proto = value
+			}
+		})
+	default:
+		fr.For = lastHeaderElement(r.Header, "X-Forwarded-For")
+		fr.Host = lastHeaderElement(r.Header, "X-Forwarded-Host")
+		fr.Proto = lastHeaderElement(r.Header, "X-Forwarded-Proto")
+	}
+
+	if fr.Host != "" && !validHostPort(fr.Host) {
+		fr.Host = ""
+	}
+
+	fr.Proto = strings.ToLower(fr.Proto)
+
+	// The prefix is used in redirects so anything that is not a plain path, such as //example.com, is ignored
+	prefix := strings.TrimSuffix(lastHeaderElement(r.Header, "X-Forwarded-Prefix"), "/")
+	if strings.HasPrefix(prefix, "/") && !strings.HasPrefix(prefix, "//") {
+		fr.Prefix = prefix
+	}
+
+	return fr
+}
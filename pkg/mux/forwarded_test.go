@@ -0,0 +1,172 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+func newForwardedMux(t *testing.T, forwarded **muxcontext.ForwardedRequest) *mux.HttpMux {
+	t.Helper()
+
+	m := mux.NewHTTP()
+	m.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		*forwarded = muxcontext.Forwarded(r.Context())
+		w.Write([]byte("internal"))
+	})
+
+	h, err := m.NewHost("{tenant}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.Handle(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*forwarded = muxcontext.Forwarded(r.Context())
+		w.Write([]byte(muxcontext.HostParam(r.Context(), "tenant")))
+	}))
+
+	return m
+}
+
+func TestServeHTTPForwarded(t *testing.T) {
+	var forwarded *muxcontext.ForwardedRequest
+	m := newForwardedMux(t, &forwarded)
+
+	// httptest requests are made from 192.0.2.1
+	m.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+	m.ForwardedHeaders = mux.ForwardedHeadersRFC7239
+
+	r := httptest.NewRequest(http.MethodGet, "http://internal/", nil)
+	r.Header.Set("X-Forwarded-Host", "spoofed.example.com")
+	r.Header.Add("Forwarded", `for=198.51.100.1;host=spoofed.example.com`)
+	r.Header.Add("Forwarded", `for="[2001:db8::1]:4711";proto=HTTPS;host="acme.example.com"`)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Body.String() != "acme" {
+		t.Errorf("Expected the forwarded host to be routed, got '%s'", w.Body.String())
+	}
+
+	expected := muxcontext.ForwardedRequest{For: "[2001:db8::1]:4711", Host: "acme.example.com", Proto: "https"}
+	if forwarded == nil || *forwarded != expected {
+		t.Errorf("Expected forwarded values %+v, got %+v", expected, forwarded)
+	}
+}
+
+func TestServeHTTPXForwarded(t *testing.T) {
+	var forwarded *muxcontext.ForwardedRequest
+	m := newForwardedMux(t, &forwarded)
+	m.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")}
+
+	r := httptest.NewRequest(http.MethodGet, "http://internal/", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 198.51.100.2")
+	r.Header.Set("X-Forwarded-Host", "spoofed.example.com, acme.example.com")
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Prefix", "/app/")
+	r.Header.Set("Forwarded", "host=spoofed.example.com;proto=http")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Body.String() != "acme" {
+		t.Errorf("Expected the forwarded host to be routed, got '%s'", w.Body.String())
+	}
+
+	expected := muxcontext.ForwardedRequest{For: "198.51.100.2", Host: "acme.example.com", Proto: "https", Prefix: "/app"}
+	if forwarded == nil || *forwarded != expected {
+		t.Errorf("Expected forwarded values %+v, got %+v", expected, forwarded)
+	}
+}
+
+func TestServeHTTPForwardedUntrusted(t *testing.T) {
+	var forwarded *muxcontext.ForwardedRequest
+	m := newForwardedMux(t, &forwarded)
+	m.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "http://internal/", nil)
+	r.Header.Set("Forwarded", "host=acme.example.com")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Body.String() != "internal" || forwarded != nil {
+		t.Errorf("Expected headers from an untrusted peer to be ignored, got '%s'", w.Body.String())
+	}
+}
+
+func TestServeHTTPForwardedNoFallback(t *testing.T) {
+	var forwarded *muxcontext.ForwardedRequest
+	m := newForwardedMux(t, &forwarded)
+	m.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")}
+
+	// The proxy sets X-Forwarded-* headers and passes the Forwarded header of the client through
+	r := httptest.NewRequest(http.MethodGet, "http://internal/", nil)
+	r.Header.Set("Forwarded", "host=evil.example.com;proto=https")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Body.String() != "internal" || forwarded != nil {
+		t.Errorf("Expected the Forwarded header to be ignored, got '%s' %+v", w.Body.String(), forwarded)
+	}
+
+	// The proxy sets the Forwarded header and passes the X-Forwarded-* headers of the client through
+	m.ForwardedHeaders = mux.ForwardedHeadersRFC7239
+
+	r = httptest.NewRequest(http.MethodGet, "http://internal/", nil)
+	r.Header.Set("X-Forwarded-Host", "evil.example.com")
+	r.Header.Set("X-Forwarded-Proto", "https")
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Body.String() != "internal" || forwarded != nil {
+		t.Errorf("Expected the X-Forwarded-* headers to be ignored, got '%s' %+v", w.Body.String(), forwarded)
+	}
+}
+
+func TestServeHTTPForwardedInvalidHost(t *testing.T) {
+	var forwarded *muxcontext.ForwardedRequest
+	m := newForwardedMux(t, &forwarded)
+	m.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")}
+
+	tests := []struct {
+		host  string
+		valid bool
+	}{
+		{"acme.example.com", true},
+		{"acme.example.com:8443", true},
+		{"127.0.0.1:8080", true},
+		{"[2001:db8::1]", true},
+		{"[2001:db8::1]:443", true},
+		{"2001:db8::1", false},
+		{"[127.0.0.1]", false},
+		{"acme.example.com:", false},
+		{"acme.example.com:https", false},
+		{"evil.com/acme.example.com", false},
+		{"user@acme.example.com", false},
+		{"acme..example.com", false},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://internal/", nil)
+		r.Header.Set("X-Forwarded-Host", test.host)
+		r.Header.Set("X-Forwarded-Proto", "https")
+
+		forwarded = nil
+		m.ServeHTTP(httptest.NewRecorder(), r)
+
+		if forwarded == nil {
+			t.Errorf("Expected forwarded values for '%s'", test.host)
+			continue
+		}
+
+		if valid := forwarded.Host == test.host; valid != test.valid {
+			t.Errorf("Expected host '%s' to be valid: %t, got '%s'", test.host, test.valid, forwarded.Host)
+		}
+	}
+}
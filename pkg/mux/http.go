@@ -1,15 +1,23 @@
 package mux
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"reflect"
 	"runtime/debug"
 	"strings"
 
 	"proto.zip/studio/mux/pkg/muxcontext"
+	"proto.zip/studio/mux/pkg/predicate"
+	"proto.zip/studio/mux/pkg/resource"
 	"proto.zip/studio/validate/pkg/errors"
 )
 
+// loggedPanicKey is the context key for a recovered panic error that has already been logged.
+type loggedPanicKey struct{}
+
 // HttpErrorHandler represents a handler function interface for router.Mux implementations that use the
 // standard HTTP server method.
 //
@@ -19,6 +27,27 @@ type HttpErrorHandler func(error, http.ResponseWriter, *http.Request)
 // HttpMux Implementation of the router.Mux pattern using standard HTTP server method.
 type HttpMux struct {
 	Mux[http.Handler, HttpErrorHandler]
+	Logger *slog.Logger // The logger used for errors and panics. Nil will use slog.Default(). Hosts may override this.
+
+	// PoolRouteContexts reuses route contexts between requests to reduce the memory allocated per request.
+	// When enabled, the muxcontext values of a request are cleared once ServeHTTP returns. The request context
+	// itself stays valid, so goroutines that outlive the request can still use it for cancellation and other values,
+	// but they must read any muxcontext values they need before the handler returns.
+	PoolRouteContexts bool
+
+	// UnknownHostStatus is the status code served for unknown hosts in strict host mode, see SetStrictHosts.
+	// It should be 421 Misdirected Request or 400 Bad Request. Zero uses 421.
+	UnknownHostStatus int
+
+	// TrustedProxies are the networks of the proxies in front of the mux, e.g. netip.MustParsePrefix("10.0.0.0/8").
+	// For requests from these peers the host used for routing and the scheme used by host policies are taken from
+	// the headers selected by ForwardedHeaders. The original values are available through muxcontext.Forwarded.
+	// Headers from other peers are ignored.
+	TrustedProxies []netip.Prefix
+
+	// ForwardedHeaders selects the headers the trusted proxies set. The zero value reads the X-Forwarded-* headers.
+	// The other headers are never read since proxies usually pass them through from the client unchanged.
+	ForwardedHeaders ForwardedHeaders
 }
 
 // HttpError implementation of the error interface for HTTP specific errors to
@@ -40,23 +69,78 @@ func NewHttpError(code int) HttpError {
 	}
 }
 
+// ErrorStatusCode returns the HTTP status code that should be served for an error.
+//
+// HttpError values carry their own status code, validation errors map to 400 and all other errors map to 500.
+func ErrorStatusCode(err error) int {
+	switch errCast := err.(type) {
+	case HttpError:
+		return errCast.StatusCode
+	case errors.ValidationError:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requestLogAttrs returns the structured logging attributes that describe the route of a request.
+func requestLogAttrs(r *http.Request) []slog.Attr {
+	ctx := r.Context()
+
+	attrs := make([]slog.Attr, 0, 6)
+	attrs = append(attrs, slog.String("method", r.Method))
+
+	if pattern := muxcontext.HostPattern(ctx); pattern != "" {
+		attrs = append(attrs, slog.String("host_pattern", pattern))
+	}
+	if pattern := muxcontext.PathPattern(ctx); pattern != "" {
+		attrs = append(attrs, slog.String("path_pattern", pattern))
+	}
+	if params := muxcontext.HostParams(ctx); params != nil {
+		attrs = append(attrs, slog.Any("host_params", params))
+	}
+	if params := muxcontext.PathParams(ctx); params != nil {
+		attrs = append(attrs, slog.Any("path_params", params))
+	}
+
+	return attrs
+}
+
+// requestLogger returns the logger associated with the request or slog.Default() if there is none.
+func requestLogger(r *http.Request) *slog.Logger {
+	if logger := muxcontext.Logger(r.Context()); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// logUnhandledError logs an error that was not expected by the mux along with the route information and stack.
+func logUnhandledError(err error, r *http.Request) {
+	if logged, ok := r.Context().Value(loggedPanicKey{}).(error); ok && reflect.TypeOf(err).Comparable() && logged == err {
+		// ServeHTTP already logged this error with the panic that raised it.
+		return
+	}
+	attrs := append(requestLogAttrs(r), slog.Any("error", err), slog.String("stack", string(debug.Stack())))
+	requestLogger(r).LogAttrs(r.Context(), slog.LevelError, "unhandled server error", attrs...)
+}
+
 // DefaultErrorHandler is called when an error occurs processing the request and no host specific
 // error handler was assigned.
 //
 // If the error is an HttpError it will serve the status text as a string.
 // If it is a validation error on path or host it will return 404.
 // If it is a validation error on query string or body it will return 400.
-// Otherwise it will log the error stack and return a 500 error.
+// Otherwise it will log the error stack using the request logger and return a 500 error.
 func DefaultErrorHandler(err error, w http.ResponseWriter, r *http.Request) {
-	switch errCast := err.(type) {
+	switch err.(type) {
 	case HttpError:
-		w.WriteHeader(errCast.StatusCode)
+		w.WriteHeader(ErrorStatusCode(err))
 		w.Write([]byte(err.Error()))
 	case errors.ValidationError:
 		w.WriteHeader(400)
 		w.Write([]byte(http.StatusText(400)))
 	default:
-		fmt.Printf("Unhandled server error: %s\n%s\n", err, string(debug.Stack()))
+		logUnhandledError(err, r)
 		w.WriteHeader(500)
 		w.Write([]byte(http.StatusText(500)))
 	}
@@ -74,83 +158,225 @@ func NewHTTP() *HttpMux {
 	return m
 }
 
-// serveHTTPError is a private helper method to serve up an HTTP error using the host error handler if applicable.
-// Otherwise DefaultErrorHandler is used.
-func (m *HttpMux) serveHTTPError(err error, w http.ResponseWriter, r *http.Request) {
-	host := muxcontext.Host[http.Handler, HttpErrorHandler](r.Context())
+// errorHandler resolves the error handler for a request.
+//
+// Error handlers are resolved innermost-first: the resource, the route group and its parents, the host and the
+// default host. If none of them have an error handler DefaultErrorHandler is returned.
+func (m *HttpMux) errorHandler(r *http.Request) HttpErrorHandler {
+	ctx := r.Context()
+	host := muxcontext.Host[http.Handler, HttpErrorHandler](ctx)
+
+	if resource := muxcontext.Resource[http.Handler](ctx); resource != nil && host != nil {
+		if handler, ok := host.ResourceErrorHandler(resource); ok && handler != nil {
+			return handler
+		}
+
+		for group := host.ResourceGroup(resource); group != nil; group = group.Parent() {
+			if group.ErrorHandler != nil {
+				return group.ErrorHandler
+			}
+		}
+	}
 
 	if host != nil && host.ErrorHandler != nil {
-		host.ErrorHandler(err, w, r)
-		return
+		return host.ErrorHandler
 	}
 
-	defaultHost := m.DefaultHost()
-	if defaultHost.ErrorHandler != nil {
-		defaultHost.ErrorHandler(err, w, r)
-		return
+	if defaultHost := m.DefaultHost(); defaultHost.ErrorHandler != nil {
+		return defaultHost.ErrorHandler
+	}
+
+	return DefaultErrorHandler
+}
+
+// unknownHostStatus returns the status code served for unknown hosts in strict host mode.
+func (m *HttpMux) unknownHostStatus() int {
+	if m.UnknownHostStatus == 0 {
+		return http.StatusMisdirectedRequest
+	}
+	return m.UnknownHostStatus
+}
+
+// unmatchedStatus returns the status code served when none of the handlers of the resource serve a request with the
+// method. The error is the one returned by MatchMethod.
+func unmatchedStatus(res *resource.Resource[http.Handler], method string, err error) int {
+	switch {
+	case err == resource.ErrUnsupportedMediaType:
+		// No media type variant consumes the request body
+		return http.StatusUnsupportedMediaType
+	case err == resource.ErrNotAcceptable:
+		// No media type variant produces an acceptable response
+		return http.StatusNotAcceptable
+	case res.HasMethod(method):
+		// Has conditional handlers for the method but none of them serve the request
+		return http.StatusNotFound
+	case len(res.Methods()) > 0:
+		// Has other methods but this isn't one
+		return http.StatusMethodNotAllowed
+	default:
+		// Has no methods at all
+		return http.StatusNotFound
 	}
+}
 
-	DefaultErrorHandler(err, w, r)
+// serveHTTPError is a private helper method to serve up an HTTP error using the most specific error handler.
+// See errorHandler for the order in which error handlers are resolved.
+func (m *HttpMux) serveHTTPError(err error, w http.ResponseWriter, r *http.Request) {
+	m.errorHandler(r)(err, w, r)
 }
 
 // ServeHTTP implements the standard HTTP interface can be used with most libraries that support HTTP handlers.
 //
-// This method modifies the request context. The following will be stored abd can be accessed with the muxcontext package:
-// - The Host for the request.
-// - The Resource for the request
+// This method modifies the request context. A single muxcontext.RouteContext is stored and the following can be
+// accessed with the muxcontext package:
+// - The Host for the request and its pattern.
+// - The Resource for the request and its pattern
 // - The parameters parsed from the URL path
 // - The parameters parsed from the hostname
+// - The logger for the request, if the host or mux has one
+// - The original request values reported by a trusted proxy, see TrustedProxies
+// - The locale from the path prefix, if the host has locales
+// - The variant that serves the request, if the method has a split
+//
+// If the host has a policy it is applied before the resource is looked up, which may serve a redirect instead.
+// Requests to case-insensitive hosts are redirected to the casing of the pattern after the lookup if the policy
+// enables RedirectPathCase.
+//
+// Requests to hosts with locales are routed by the path without the language prefix. Paths without a prefix are
+// redirected to the supported language that best matches the Accept-Language header, if they match a resource.
 func (m *HttpMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	var rc *muxcontext.RouteContext
+	if m.PoolRouteContexts {
+		rc = muxcontext.AcquireRouteContext()
+		ctx := muxcontext.WithPooledRouteContext(r.Context(), rc)
+		defer muxcontext.ReleasePooledRouteContext(ctx)
+		r = r.WithContext(ctx)
+	} else {
+		rc = new(muxcontext.RouteContext)
+		r = r.WithContext(muxcontext.WithRouteContext(r.Context(), rc))
+	}
 
 	defer func() {
 		if err := recover(); err != nil {
-			switch errCast := err.(type) {
-			case error:
-				m.serveHTTPError(errCast, w, r.WithContext(ctx))
-			default:
-				fmt.Printf("%v\n", err)
-				m.serveHTTPError(NewHttpError(http.StatusInternalServerError), w, r.WithContext(ctx))
+			attrs := append(requestLogAttrs(r), slog.Any("panic", err), slog.String("stack", string(debug.Stack())))
+			requestLogger(r).LogAttrs(r.Context(), slog.LevelError, "panic serving request", attrs...)
+
+			errCast, ok := err.(error)
+			if !ok {
+				errCast = NewHttpError(http.StatusInternalServerError)
 			}
+			r = r.WithContext(context.WithValue(r.Context(), loggedPanicKey{}, errCast))
+			m.serveHTTPError(errCast, w, r)
 		}
 	}()
 
-	host, hostParamValues := m.Host(r.Host)
-	resource, pathParamValues := host.Resource([]byte(r.URL.Path))
+	hostname := r.Host
+	if len(m.TrustedProxies) > 0 && m.trustedPeer(r) {
+		rc.Forwarded = forwardedRequest(r, m.ForwardedHeaders)
+		if rc.Forwarded.Host != "" {
+			hostname = rc.Forwarded.Host
+		}
+	}
+
+	host, hostParamValues, err := m.ResolveHost(hostname, rc.HostValues())
 
-	ctx = muxcontext.WithHost(ctx, host)
+	rc.Host = host
+
+	if host.Logger != nil {
+		rc.Logger = host.Logger
+	} else {
+		rc.Logger = m.Logger
+	}
+
+	if err == ErrUnknownHost {
+		m.serveHTTPError(NewHttpError(m.unknownHostStatus()), w, r)
+		return
+	} else if err != nil {
+		m.serveHTTPError(err, w, r)
+		return
+	}
+
+	rc.HostParams = muxcontext.NewParams(host.ParamNames(), hostParamValues)
+
+	if host.Policy != nil && applyPolicy(host.Policy, hostname, rc, w, r) {
+		return
+	}
+
+	path := r.URL.Path
+	localePrefix := ""
+
+	if host.Locales != nil {
+		var ok bool
+		if rc.Locale, path, ok = host.Locales.Split(path); !ok {
+			if !redirectLocale(host, rc, w, r) {
+				m.serveHTTPError(NewHttpError(http.StatusNotFound), w, r)
+			}
+			return
+		}
+		localePrefix = host.Locales.Prefix(rc.Locale)
+	}
+
+	resource, pathParamValues := host.AppendResource(path, rc.PathValues())
 
 	if resource == nil {
-		m.serveHTTPError(NewHttpError(http.StatusNotFound), w, r.WithContext(ctx))
+		m.serveHTTPError(NewHttpError(http.StatusNotFound), w, r)
 		return
 	}
 
-	ctx = muxcontext.WithResource(ctx, resource)
+	rc.Resource = resource
+
+	if host.Policy != nil && host.Policy.RedirectPathCase && host.CaseInsensitive() && applyPathCase(host.Policy, host, localePrefix, path, rc, w, r) {
+		return
+	}
 
 	// Normalize the method name to upper since this is be taken straight from the request header
 	r.Method = strings.ToUpper(r.Method)
 
-	handler, ok := resource.Method(r.Method)
+	if resource.Negotiates(r.Method) {
+		w.Header().Add("Vary", "Accept")
+	}
 
-	if ok {
-		paramMap := resource.ParamMap(r.Method, pathParamValues)
-		if paramMap != nil {
-			ctx = muxcontext.WithPathParams(ctx, paramMap)
-		}
+	handler, variant, err := resource.MatchMethod(r.Method, routeRequest{r: r})
 
-		paramMap = host.ParamMap(hostParamValues)
-		if paramMap != nil {
-			ctx = muxcontext.WithHostParams(ctx, paramMap)
-		}
+	if err != nil {
+		m.serveHTTPError(NewHttpError(unmatchedStatus(resource, r.Method, err)), w, r)
+		return
+	}
 
-		any(handler).(http.Handler).ServeHTTP(w, r.WithContext(ctx))
-	} else if len(resource.Methods()) > 0 {
-		// 405 Method Not Allowed - Has other methods but this isn't one
-		m.serveHTTPError(NewHttpError(http.StatusMethodNotAllowed), w, r.WithContext(ctx))
-	} else {
-		// 404 Not Found - Has no methods at all
-		m.serveHTTPError(NewHttpError(http.StatusNotFound), w, r.WithContext(ctx))
+	pathParamValues = append(pathParamValues, resource.ParamDefaults(r.Method)...)
+	rc.PathParams = muxcontext.NewParams(resource.ParamNames(r.Method), pathParamValues)
+	rc.Variant = variant
+
+	any(handler).(http.Handler).ServeHTTP(w, r)
+}
+
+// routeRequest is the predicate.Request view of a request served by the mux. Unlike predicate.HTTPRequest it has the
+// host parameters of the route context. It only holds a pointer so converting it to the interface does not allocate.
+type routeRequest struct {
+	r *http.Request
+}
+
+// Header returns the first value of the header.
+func (r routeRequest) Header(name string) string {
+	return predicate.HTTPRequest(r.r).Header(name)
+}
+
+// Query returns the first value of the query parameter and whether it is present.
+func (r routeRequest) Query(name string) (string, bool) {
+	return predicate.HTTPRequest(r.r).Query(name)
+}
+
+// Cookie returns the value of the cookie and whether it is present.
+func (r routeRequest) Cookie(name string) (string, bool) {
+	return predicate.HTTPRequest(r.r).Cookie(name)
+}
+
+// HostParam returns the value of the parameter of the host pattern and whether it is present.
+func (r routeRequest) HostParam(name string) (string, bool) {
+	if rc := muxcontext.Route(r.r.Context()); rc != nil {
+		return rc.HostParams.Get(name)
 	}
+	return "", false
 }
 
 // HandleFunc registers a new function request handler.
@@ -0,0 +1,135 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/resource"
+)
+
+// discardResponseWriter is a minimal http.ResponseWriter that does not allocate.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(statusCode int)  {}
+
+func benchmarkServeHTTP(b *testing.B, m *mux.HttpMux, url string) {
+	b.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, url, nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		m.ServeHTTP(w, r)
+	}
+}
+
+func newBenchmarkMux(b *testing.B) *mux.HttpMux {
+	b.Helper()
+
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	m := mux.NewHTTP()
+	m.Handle(http.MethodGet, "/api/v1/internal/admin/reports", noop)
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		b.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.Handle(http.MethodGet, "/docs/{id}", noop)
+	h.Handle(http.MethodGet, "/docs/{id}/revisions/{revision}", noop)
+
+	return m
+}
+
+func BenchmarkServeHTTP_Static(b *testing.B) {
+	benchmarkServeHTTP(b, newBenchmarkMux(b), "http://localhost/api/v1/internal/admin/reports")
+}
+
+func BenchmarkServeHTTP_Params(b *testing.B) {
+	benchmarkServeHTTP(b, newBenchmarkMux(b), "http://test.example.com/docs/123/revisions/456")
+}
+
+func BenchmarkServeHTTP_NotFound(b *testing.B) {
+	benchmarkServeHTTP(b, newBenchmarkMux(b), "http://localhost/missing")
+}
+
+func BenchmarkServeHTTP_StaticPooled(b *testing.B) {
+	m := newBenchmarkMux(b)
+	m.PoolRouteContexts = true
+	benchmarkServeHTTP(b, m, "http://localhost/api/v1/internal/admin/reports")
+}
+
+func BenchmarkServeHTTP_ParamsPooled(b *testing.B) {
+	m := newBenchmarkMux(b)
+	m.PoolRouteContexts = true
+	benchmarkServeHTTP(b, m, "http://test.example.com/docs/123/revisions/456")
+}
+
+func TestServeHTTPAllocations(t *testing.T) {
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	m := mux.NewHTTP()
+	m.PoolRouteContexts = true
+	m.Handle(http.MethodGet, "/docs/{id}", noop)
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/docs/123", nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+
+	// The remaining allocations are the request copy made by WithContext and the small context value that references
+	// the pooled route context.
+	allocs := testing.AllocsPerRun(100, func() {
+		m.ServeHTTP(w, r)
+	})
+	if allocs > 2 {
+		t.Errorf("Expected at most 2 allocations, got %f", allocs)
+	}
+
+	// Without pooling the route context, which is also the context value, is allocated instead.
+	m.PoolRouteContexts = false
+	allocs = testing.AllocsPerRun(100, func() {
+		m.ServeHTTP(w, r)
+	})
+	if allocs > 2 {
+		t.Errorf("Expected at most 2 allocations without pooling, got %f", allocs)
+	}
+}
+
+func TestServeHTTPMediaAllocations(t *testing.T) {
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	m := mux.NewHTTP()
+	m.PoolRouteContexts = true
+	m.HandleMedia(http.MethodPost, "/docs/{id}", resource.MediaType{Consumes: "application/json", Produces: "application/json"}, noop)
+	m.HandleMedia(http.MethodPost, "/docs/{id}", resource.MediaType{Consumes: "multipart/*", Produces: "text/html"}, noop)
+	m.HandleMedia(http.MethodPost, "/docs/{id}", resource.MediaType{Consumes: "application/json", Produces: "text/html"}, noop)
+
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/docs/123", nil)
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("Accept", "text/html;q=0.9, application/json;q=0.5, */*;q=0.1")
+	w := &discardResponseWriter{header: make(http.Header)}
+
+	// Matching the media type variants adds no allocations to the request copy made by WithContext and the context
+	// value of the pooled route context.
+	allocs := testing.AllocsPerRun(100, func() {
+		m.ServeHTTP(w, r)
+	})
+	if allocs > 2 {
+		t.Errorf("Expected at most 2 allocations, got %f", allocs)
+	}
+}
+
+func BenchmarkServeHTTP_ParamsFrozen(b *testing.B) {
+	m := newBenchmarkMux(b)
+	m.PoolRouteContexts = true
+	m.Freeze()
+	benchmarkServeHTTP(b, m, "http://test.example.com/docs/123/revisions/456")
+}
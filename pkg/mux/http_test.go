@@ -0,0 +1,451 @@
+package mux_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+func decodeLogRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	record := make(map[string]any)
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Unexpected error decoding log record '%s': %s", buf.String(), err)
+	}
+	return record
+}
+
+func TestServeHTTPLogsPanic(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := mux.NewHTTP()
+	m.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.Handle(http.MethodGet, "/docs/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://test.example.com/docs/123", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+
+	record := decodeLogRecord(t, &buf)
+
+	if record["panic"] != "boom" {
+		t.Errorf("Expected panic attribute to be 'boom', got %v", record["panic"])
+	}
+	if record["host_pattern"] != "{db}.example.com" {
+		t.Errorf("Unexpected host pattern %v", record["host_pattern"])
+	}
+	if record["path_pattern"] != "/docs/{id}" {
+		t.Errorf("Unexpected path pattern %v", record["path_pattern"])
+	}
+	if record["method"] != http.MethodGet {
+		t.Errorf("Unexpected method %v", record["method"])
+	}
+	if params, ok := record["path_params"].(map[string]any); !ok || params["id"] != "123" {
+		t.Errorf("Unexpected path params %v", record["path_params"])
+	}
+	if params, ok := record["host_params"].(map[string]any); !ok || params["db"] != "test" {
+		t.Errorf("Unexpected host params %v", record["host_params"])
+	}
+	if stack, ok := record["stack"].(string); !ok || stack == "" {
+		t.Error("Expected stack to be logged")
+	}
+}
+
+func TestServeHTTPHostLogger(t *testing.T) {
+	var muxBuf, hostBuf bytes.Buffer
+
+	m := mux.NewHTTP()
+	m.Logger = slog.New(slog.NewJSONHandler(&muxBuf, nil))
+
+	h, err := m.NewHost("example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.Logger = slog.New(slog.NewJSONHandler(&hostBuf, nil))
+	h.Handle(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("failed"))
+	}))
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+
+	if muxBuf.Len() != 0 {
+		t.Errorf("Expected mux logger to be unused, got '%s'", muxBuf.String())
+	}
+
+	record := decodeLogRecord(t, &hostBuf)
+
+	if record["panic"] != "failed" {
+		t.Errorf("Expected panic attribute to be 'failed', got %v", record["panic"])
+	}
+}
+
+func TestServeHTTPLogsErrorPanic(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := mux.NewHTTP()
+	m.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.ErrorHandler = errorHandlerWithBody("handled")
+	h.Handle(http.MethodGet, "/docs/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(mux.NewHttpError(http.StatusConflict))
+	}))
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://test.example.com/docs/123", nil))
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+	if w.Body.String() != "handled" {
+		t.Errorf("Expected error handler to serve the response, got '%s'", w.Body.String())
+	}
+
+	record := decodeLogRecord(t, &buf)
+
+	if record["msg"] != "panic serving request" {
+		t.Errorf("Unexpected log message %v", record["msg"])
+	}
+	if record["host_pattern"] != "{db}.example.com" {
+		t.Errorf("Unexpected host pattern %v", record["host_pattern"])
+	}
+	if record["path_pattern"] != "/docs/{id}" {
+		t.Errorf("Unexpected path pattern %v", record["path_pattern"])
+	}
+	if stack, ok := record["stack"].(string); !ok || stack == "" {
+		t.Error("Expected stack to be logged")
+	}
+}
+
+func errorHandlerWithBody(body string) mux.HttpErrorHandler {
+	return func(err error, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(mux.ErrorStatusCode(err))
+		w.Write([]byte(body))
+	}
+}
+
+func TestServeHTTPErrorHandlerChain(t *testing.T) {
+	m := mux.NewHTTP()
+	m.DefaultHost().ErrorHandler = errorHandlerWithBody("default")
+
+	h, err := m.NewHost("example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	api := h.Group("/api")
+	api.ErrorHandler = errorHandlerWithBody("group")
+
+	v1 := api.Group("v1")
+	v1.Handle(http.MethodGet, "/docs", noop)
+	v1.Handle(http.MethodGet, "/users", noop)
+
+	res, _, err := v1.NewResource([]byte("/users"))
+	if err != nil {
+		t.Fatalf("Unexpected error fetching resource: %s", err)
+	}
+	if err := h.SetResourceErrorHandler(res, errorHandlerWithBody("resource")); err != nil {
+		t.Fatalf("Unexpected error setting resource error handler: %s", err)
+	}
+
+	h.Handle(http.MethodGet, "/about", noop)
+
+	tests := []struct {
+		url  string
+		body string
+	}{
+		{"http://example.com/api/v1/users", "resource"},
+		{"http://example.com/api/v1/docs", "group"},
+		{"http://example.com/about", "default"},
+		{"http://other.com/about", "default"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, test.url, nil))
+
+		if body := w.Body.String(); body != test.body {
+			t.Errorf("Expected error handler '%s' for %s, got '%s'", test.body, test.url, body)
+		}
+	}
+
+	h.ErrorHandler = errorHandlerWithBody("host")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://example.com/about", nil))
+
+	if body := w.Body.String(); body != "host" {
+		t.Errorf("Expected host error handler, got '%s'", body)
+	}
+
+	if pattern := res.Pattern(); pattern != "/api/v1/users" {
+		t.Errorf("Expected group prefix in resource pattern, got '%s'", pattern)
+	}
+}
+
+func TestServeHTTPPatterns(t *testing.T) {
+	m := mux.NewHTTP()
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	var hostPattern, pathPattern string
+	h.Handle(http.MethodGet, "/docs/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hostPattern = muxcontext.HostPattern(r.Context())
+		pathPattern = muxcontext.PathPattern(r.Context())
+	}))
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://test.example.com/docs/123", nil))
+
+	if hostPattern != "{db}.example.com" {
+		t.Errorf("Expected host pattern to be '{db}.example.com', got '%s'", hostPattern)
+	}
+	if pathPattern != "/docs/{id}" {
+		t.Errorf("Expected path pattern to be '/docs/{id}', got '%s'", pathPattern)
+	}
+}
+
+func TestServeHTTPPooledRouteContext(t *testing.T) {
+	m := mux.NewHTTP()
+	m.PoolRouteContexts = true
+
+	var ids []string
+	m.HandleFunc(http.MethodGet, "/docs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, muxcontext.PathParams(r.Context())["id"])
+
+		if rc := muxcontext.Route(r.Context()); rc == nil || rc.PathPattern() != "/docs/{id}" {
+			t.Error("Expected route context with path pattern")
+		}
+	})
+
+	for _, id := range []string{"1", "2"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/docs/"+id, nil))
+	}
+
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("Expected ids [1 2], got %v", ids)
+	}
+}
+
+func TestServeHTTPPooledRouteContextDerived(t *testing.T) {
+	m := mux.NewHTTP()
+	m.PoolRouteContexts = true
+
+	var derived []context.Context
+	m.HandleFunc(http.MethodGet, "/docs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		derived = append(derived, muxcontext.WithLogger(r.Context(), slog.Default()))
+	})
+
+	for _, id := range []string{"1", "2"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/docs/"+id, nil))
+	}
+
+	// The route context of the first request was released and reused by the second
+	for i, id := range []string{"1", "2"} {
+		if value := muxcontext.PathParam(derived[i], "id"); value != id {
+			t.Errorf("Expected derived context %d to have id '%s', got '%s'", i, id, value)
+		}
+	}
+}
+
+type keptContextKey struct{}
+
+func TestServeHTTPPooledRouteContextKept(t *testing.T) {
+	m := mux.NewHTTP()
+	m.PoolRouteContexts = true
+
+	var kept []context.Context
+	m.HandleFunc(http.MethodGet, "/docs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		kept = append(kept, r.Context())
+	})
+
+	var cancels []context.CancelFunc
+	for _, id := range []string{"1", "2"} {
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), keptContextKey{}, id))
+		cancels = append(cancels, cancel)
+
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/docs/"+id, nil).WithContext(ctx)
+		m.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	cancels[0]()
+	defer cancels[1]()
+
+	for i, id := range []string{"1", "2"} {
+		ctx := kept[i]
+
+		if value := ctx.Value(keptContextKey{}); value != id {
+			t.Errorf("Expected kept context %d to have value '%s', got %v", i, id, value)
+		}
+
+		if rc := muxcontext.Route(ctx); rc != nil {
+			t.Errorf("Expected kept context %d to not have a route context after the request", i)
+		}
+
+		select {
+		case <-ctx.Done():
+			if i != 0 || ctx.Err() != context.Canceled {
+				t.Errorf("Expected only the first kept context to be canceled, context %d: %v", i, ctx.Err())
+			}
+		default:
+			if i == 0 {
+				t.Error("Expected first kept context to be canceled")
+			}
+		}
+	}
+}
+
+func TestServeHTTPParamsConcurrent(t *testing.T) {
+	m := mux.NewHTTP()
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	h.Handle(http.MethodGet, "/docs/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if id := muxcontext.PathParams(r.Context())["id"]; id != "123" {
+					t.Errorf("Expected id '123', got '%s'", id)
+				}
+				if db := muxcontext.HostParams(r.Context())["db"]; db != "test" {
+					t.Errorf("Expected db 'test', got '%s'", db)
+				}
+			}()
+		}
+		wg.Wait()
+	}))
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://test.example.com/docs/123", nil))
+}
+
+func TestServeHTTPStrictHosts(t *testing.T) {
+	m := mux.NewHTTP()
+	m.DefaultHost().ErrorHandler = errorHandlerWithBody("default")
+	m.SetStrictHosts(true, "LocalHost", "::1")
+
+	m.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("root"))
+	})
+
+	h, err := m.NewHost("example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.Handle(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("example"))
+	}))
+
+	tenant, err := m.NewHost("{tenant}.Example.org")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	tenant.Handle(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(muxcontext.HostParam(r.Context(), "tenant")))
+	}))
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"http://localhost/", http.StatusOK, "root"},
+		{"http://example.com:8080/", http.StatusOK, "example"},
+		{"http://EXAMPLE.com/", http.StatusOK, "example"},
+		{"http://Example.Com:8443/", http.StatusOK, "example"},
+		{"http://acme.example.org/", http.StatusOK, "acme"},
+		{"http://ACME.EXAMPLE.ORG:8080/", http.StatusOK, "acme"},
+		{"http://localhost:8080/", http.StatusOK, "root"},
+		{"http://LOCALHOST/", http.StatusOK, "root"},
+		{"http://[::1]:8080/", http.StatusOK, "root"},
+		{"http://attacker.com:8080/", http.StatusMisdirectedRequest, "default"},
+		{"http://example.com/", http.StatusOK, "example"},
+		{"http://attacker.com/", http.StatusMisdirectedRequest, "default"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("Expected '%s' to return %d '%s', got %d '%s'", test.url, test.status, test.body, w.Code, w.Body.String())
+		}
+	}
+
+	m.UnknownHostStatus = http.StatusBadRequest
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://attacker.com/", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	m.SetStrictHosts(false)
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://attacker.com/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected default host to answer once strict mode is disabled, got %d", w.Code)
+	}
+}
+
+func TestServeHTTPOptionalSegments(t *testing.T) {
+	m := mux.NewHTTP()
+	m.HandleFunc(http.MethodGet, "/reports/{year=2024}/{month?}", func(w http.ResponseWriter, r *http.Request) {
+		month, ok := muxcontext.Route(r.Context()).PathParams.Get("month")
+		w.Write([]byte(muxcontext.PathParam(r.Context(), "year") + ":" + month + ":" + strconv.FormatBool(ok)))
+	})
+
+	tests := []struct {
+		url  string
+		body string
+	}{
+		{"http://localhost/reports", "2024::false"},
+		{"http://localhost/reports/2023", "2023::false"},
+		{"http://localhost/reports/2023/06", "2023:06:true"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+		if w.Body.String() != test.body {
+			t.Errorf("Expected '%s' to return '%s', got %d '%s'", test.url, test.body, w.Code, w.Body.String())
+		}
+	}
+}
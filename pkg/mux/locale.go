@@ -0,0 +1,32 @@
+package mux
+
+import (
+	"net/http"
+
+	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+// localeRedirectStatus returns the status code used to redirect a request to its locale. The target depends on the
+// Accept-Language header so the redirect is never permanent.
+func localeRedirectStatus(r *http.Request) int {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return http.StatusFound
+	}
+	return http.StatusTemporaryRedirect
+}
+
+// redirectLocale redirects a request to a host with locales whose path has no language prefix to the supported
+// language that best matches the Accept-Language header.
+// The redirect is only served if the path matches a resource of the host, otherwise false is returned.
+func redirectLocale[RH any, EH any](h *host.Host[RH, EH], rc *muxcontext.RouteContext, w http.ResponseWriter, r *http.Request) bool {
+	if resource, _ := h.AppendResource(r.URL.Path, rc.PathValues()); resource == nil {
+		return false
+	}
+
+	locale := h.Locales.Match(r.Header.Get("Accept-Language"))
+
+	w.Header().Add("Vary", "Accept-Language")
+	http.Redirect(w, r, rc.Forwarded.Prefix+h.Locales.Prefix(locale)+r.URL.RequestURI(), localeRedirectStatus(r))
+	return true
+}
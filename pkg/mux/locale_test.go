@@ -0,0 +1,65 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/language"
+	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+func TestServeHTTPLocales(t *testing.T) {
+	m := mux.NewHTTP()
+	m.DefaultHost().Locales = host.NewLocales(language.English, language.French)
+
+	m.HandleFunc(http.MethodGet, "/docs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(muxcontext.Locale(r.Context()).String() + ":" + muxcontext.PathParam(r.Context(), "id")))
+	})
+	m.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(muxcontext.Locale(r.Context()).String()))
+	})
+
+	tests := []struct {
+		method         string
+		url            string
+		acceptLanguage string
+		status         int
+		body           string
+		location       string
+	}{
+		{http.MethodGet, "/en/docs/123", "", http.StatusOK, "en:123", ""},
+		{http.MethodGet, "/fr/docs/123", "en", http.StatusOK, "fr:123", ""},
+		{http.MethodGet, "/fr", "", http.StatusOK, "fr", ""},
+		{http.MethodGet, "/docs/123?a=1", "fr-CA, en;q=0.5", http.StatusFound, "", "/fr/docs/123?a=1"},
+		{http.MethodGet, "/docs/123", "de", http.StatusFound, "", "/en/docs/123"},
+		{http.MethodPost, "/docs/123", "fr", http.StatusTemporaryRedirect, "", "/fr/docs/123"},
+		{http.MethodGet, "/de/docs/123", "fr", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/en/missing", "", http.StatusNotFound, "", ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, nil)
+		if test.acceptLanguage != "" {
+			r.Header.Set("Accept-Language", test.acceptLanguage)
+		}
+
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("Expected %s '%s' to return %d, got %d", test.method, test.url, test.status, w.Code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("Expected %s '%s' to return '%s', got '%s'", test.method, test.url, test.body, w.Body.String())
+		}
+		if location := w.Header().Get("Location"); location != test.location {
+			t.Errorf("Expected %s '%s' to redirect to '%s', got '%s'", test.method, test.url, test.location, location)
+		}
+		if test.location != "" && w.Header().Get("Vary") != "Accept-Language" {
+			t.Errorf("Expected redirect of '%s' to vary by Accept-Language", test.url)
+		}
+	}
+}
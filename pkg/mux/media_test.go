@@ -0,0 +1,95 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/muxcontext"
+	"proto.zip/studio/mux/pkg/resource"
+)
+
+func TestServeHTTPMediaTypes(t *testing.T) {
+	m := mux.NewHTTP()
+
+	respond := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + muxcontext.PathParam(r.Context(), "id")))
+		}
+	}
+
+	m.HandleMedia(http.MethodPost, "/docs/{id}", resource.MediaType{Consumes: "application/json"}, respond("json"))
+	m.HandleMedia(http.MethodPost, "/docs/{id}", resource.MediaType{Consumes: "multipart/*"}, respond("multipart"))
+
+	m.HandleMedia(http.MethodGet, "/docs/{id}", resource.MediaType{Produces: "application/json"}, respond("json"))
+	m.HandleMedia(http.MethodGet, "/docs/{id}", resource.MediaType{Produces: "text/html"}, respond("html"))
+
+	m.HandleMedia(http.MethodPut, "/docs/{id}", resource.MediaType{Consumes: "application/json"}, respond("put-json"))
+	m.Handle(http.MethodPut, "/docs/{id}", respond("put"))
+
+	tests := []struct {
+		method      string
+		contentType string
+		accept      string
+		status      int
+		body        string
+	}{
+		{http.MethodPost, "application/json; charset=utf-8", "", http.StatusOK, "json:1"},
+		{http.MethodPost, "multipart/form-data; boundary=x", "", http.StatusOK, "multipart:1"},
+		{http.MethodPost, "text/plain", "", http.StatusUnsupportedMediaType, ""},
+		{http.MethodPost, "", "", http.StatusUnsupportedMediaType, ""},
+		{http.MethodGet, "", "", http.StatusOK, "json:1"},
+		{http.MethodGet, "", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8", http.StatusOK, "html:1"},
+		{http.MethodGet, "", "application/json;q=0.5, text/html;q=0.4", http.StatusOK, "json:1"},
+		{http.MethodGet, "", "image/png", http.StatusNotAcceptable, ""},
+		{http.MethodPut, "application/json", "", http.StatusOK, "put-json:1"},
+		{http.MethodPut, "text/plain", "", http.StatusOK, "put:1"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "/docs/1", nil)
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("Expected %s with '%s' and '%s' to return %d, got %d", test.method, test.contentType, test.accept, test.status, w.Code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("Expected %s with '%s' and '%s' to return '%s', got '%s'", test.method, test.contentType, test.accept, test.body, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/1", nil))
+	if vary := w.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("Expected negotiated responses to vary by Accept, got '%s'", vary)
+	}
+}
+
+func TestServeHTTPMediaTypesErrorHandler(t *testing.T) {
+	m := mux.NewHTTP()
+
+	var status int
+	m.DefaultHost().ErrorHandler = func(err error, w http.ResponseWriter, r *http.Request) {
+		status = mux.ErrorStatusCode(err)
+		w.WriteHeader(status)
+	}
+
+	m.HandleMedia(http.MethodGet, "/docs", resource.MediaType{Produces: "application/json"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	r.Header.Set("Accept", "text/html")
+	m.ServeHTTP(httptest.NewRecorder(), r)
+
+	if status != http.StatusNotAcceptable {
+		t.Errorf("Expected host error handler to receive 406, got %d", status)
+	}
+}
@@ -2,18 +2,40 @@
 package mux
 
 import (
+	"errors"
+	"strings"
+
+	"proto.zip/studio/mux/internal/iptable"
 	"proto.zip/studio/mux/internal/routetree"
 	"proto.zip/studio/mux/internal/tokenizers"
 	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/predicate"
+	"proto.zip/studio/mux/pkg/resource"
 	"proto.zip/studio/mux/pkg/tokenizer"
 )
 
 // Mux is an instance of a request multiplexer.
 type Mux[RequestHandlerType any, ErrorHandlerType any] struct {
-	defaultHost *host.Host[RequestHandlerType, ErrorHandlerType]
-	hosts       routetree.Node[host.Host[RequestHandlerType, ErrorHandlerType]]
+	defaultHost   *host.Host[RequestHandlerType, ErrorHandlerType]
+	hosts         routetree.Node[host.Host[RequestHandlerType, ErrorHandlerType]]
+	compiledHosts *routetree.Compiled[host.Host[RequestHandlerType, ErrorHandlerType]]
+	ipHosts       *iptable.Table[host.Host[RequestHandlerType, ErrorHandlerType]]
+	resolver      *hostResolver[RequestHandlerType, ErrorHandlerType]
+	aliases       hostAliases[RequestHandlerType, ErrorHandlerType]
+	strictHosts   bool
+	defaultNames  map[string]struct{}
 }
 
+// ErrFrozen is returned when attempting to register hosts or routes after the mux has been frozen.
+var ErrFrozen = host.ErrFrozen
+
+// ErrHostExists is returned when a shared host is created for a pattern that already has a host with other routes.
+var ErrHostExists = errors.New("host already exists with different routes")
+
+// ErrUnknownHost is returned by ResolveHost in strict host mode when the hostname does not match any host and is
+// not one of the names of the default host.
+var ErrUnknownHost = errors.New("unknown host")
+
 // WithDefaults modifies the mux by adding default internal values.
 // Required when creating a new mux. Called automatically by New() and NewHttp()
 func (m *Mux[RH, EH]) WithDefaults() *Mux[RH, EH] {
@@ -33,93 +55,312 @@ func (m *Mux[RH, EH]) DefaultHost() *host.Host[RH, EH] {
 	return m.defaultHost
 }
 
+// hostSlot holds the host of a pattern in the domain tree or the IP table.
+type hostSlot[RH any, EH any] interface {
+	Value() *host.Host[RH, EH]
+	SetValue(h *host.Host[RH, EH])
+}
+
 // NewHost creates a new host in the tree using a host pattern.
 // Returns a new or existing host or an error. The pattern can be a fully qualified hostname or contain expressions.
 //
 // Example pattern: {subdomain}.example.com
+//
+// Domain labels may mix literals and expressions, e.g. {tenant}-api.example.com or pr-{number}.example.com. Exact
+// labels take precedence over mixed labels, which take precedence over labels that are a single expression.
+//
+// The pattern may also be an IP address or a CIDR range, such as 10.0.0.5, [::1] or 10.0.0.0/8. These match
+// requests made directly to an IP address, with or without a port. A single address takes precedence over ranges
+// and smaller ranges take precedence over larger ones.
+//
+// ErrFrozen is returned if the mux has been frozen.
 func (m *Mux[RH, EH]) NewHost(hostPattern string) (*host.Host[RH, EH], error) {
+	node, paramNames, err := m.insertHost(hostPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	h := node.Value()
+	if h == nil {
+		h = host.NewWithPattern[RH, EH](hostPattern, paramNames)
+		node.SetValue(h)
+	}
+	return h, nil
+}
+
+// NewSharedHost creates a new host in the tree using a host pattern that shares the routes of template.
+// Returns a new or existing host or an error. See host.NewShared for details on how routes are shared.
+//
+// Sharing routes is useful when many hosts, such as per tenant subdomains, serve identical routes. Only the
+// per host state is stored for each host so memory use stays small with millions of hosts.
+//
+// ErrFrozen is returned if the mux has been frozen. ErrHostExists is returned if a host already exists for the
+// pattern that does not share the routes of template.
+func (m *Mux[RH, EH]) NewSharedHost(hostPattern string, template *host.Host[RH, EH]) (*host.Host[RH, EH], error) {
+	node, paramNames, err := m.insertHost(hostPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	h := node.Value()
+	if h == nil {
+		h = host.NewShared(template, hostPattern, paramNames)
+		node.SetValue(h)
+	} else if !h.SharesRoutes(template) {
+		return nil, ErrHostExists
+	}
+	return h, nil
+}
+
+// insertHost parses the host pattern and returns the slot for it in the domain tree or the IP table, creating it if
+// needed, along with the names of the pattern parameters.
+func (m *Mux[RH, EH]) insertHost(hostPattern string) (hostSlot[RH, EH], []tokenizer.Token, error) {
+	if m.compiledHosts != nil {
+		return nil, nil, ErrFrozen
+	}
+
+	if prefix, ok := iptable.ParsePattern(hostPattern); ok {
+		if m.ipHosts == nil {
+			m.ipHosts = iptable.New[host.Host[RH, EH]]()
+		}
+		return m.ipHosts.Insert(prefix), nil, nil
+	}
+
 	tok := tokenizers.NewDomainPatternTokenizer([]byte(hostPattern))
 
 	var paramNames []tokenizer.Token
+	var segments []routetree.Segment
 
-	node := m.hosts
 	token, tokenType, err := tok.Next()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for token != nil {
-		parent := node
-
-		if tokenType == tokenizer.TokenTypeLabel {
-			if paramNames == nil {
-				paramNames = make([]tokenizer.Token, 0, 1)
+		switch tokenType {
+		case tokenizer.TokenTypeLabel:
+			paramNames = append(paramNames, token)
+		case tokenizer.TokenTypePattern:
+			// The tokenizer already validated the pattern
+			_, parts, _ := tokenizers.ParseSegmentPattern(token)
+			for _, part := range parts {
+				paramNames = append(paramNames, part.Label)
 			}
-			paramNames = append(paramNames, token[1:len(token)-1])
 		}
 
-		node = node.Child(token)
-		if node == nil {
-			if tokenType == tokenizer.TokenTypeLabel {
-				node = routetree.NewWildcardNode[host.Host[RH, EH]]()
-			} else {
-				node = routetree.NewLiteralNode[host.Host[RH, EH]](token)
-			}
-			parent.AddChild(node)
-		}
+		// Hostnames are matched in lower case, see ResolveHost
+		segments = append(segments, routetree.Segment{
+			Token: lowerLiterals(string(token), tokenType),
+			Type:  tokenType,
+		})
 
 		token, tokenType, err = tok.Next()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	h := node.Value()
-	if h == nil {
-		h = host.NewWithParams[RH, EH](paramNames)
-		node.SetValue(h)
+	return routetree.Insert(m.hosts, segments, true), paramNames, nil
+}
+
+// lowerLiterals returns the token of a host pattern segment with the literals in lower case. Label names are kept.
+func lowerLiterals(token string, tokenType tokenizer.TokenType) string {
+	switch tokenType {
+	case tokenizer.TokenTypeLiteral:
+		return strings.ToLower(token)
+	case tokenizer.TokenTypePattern:
+		b := []byte(token)
+		label := false
+		for i, c := range b {
+			switch {
+			case c == '{':
+				label = true
+			case c == '}':
+				label = false
+			case !label && c >= 'A' && c <= 'Z':
+				b[i] = c + 'a' - 'A'
+			}
+		}
+		return string(b)
+	default:
+		return token
 	}
-	return h, nil
 }
 
 // Host returns a host matching the hostname or the default host if none is found.
-// This functions expects a fully qualified hostname and will not match patterns.
+// This functions expects a fully qualified hostname and will not match patterns. The port of the hostname is ignored
+// and hostnames are matched in lower case, so values of host parameters are lower case as well.
 //
-// The second return value will contain any literals that satisfied the expressions in the pattern.
+// Aliases set with SetAlias take precedence over registered hosts. Hostnames that are IP addresses are matched
+// against the IP hosts first.
+// If no registered host matches and the mux has a HostResolver it is used to find the host. Errors from the
+// resolver are ignored, use ResolveHost to handle them.
 //
-// This method never returns nil.
+// The second return value will contain any tokens that satisfied the expressions in the pattern.
+//
+// This method never returns nil. Use AppendHost to find a host without allocating.
 func (m *Mux[RH, EH]) Host(hostname string) (*host.Host[RH, EH], []tokenizer.Token) {
-	tok := tokenizers.NewDomainTokenizer([]byte(hostname))
+	h, paramValues := m.AppendHost(hostname, nil)
+	if len(paramValues) == 0 {
+		return h, nil
+	}
 
-	var paramValues []tokenizer.Token
+	tokens := make([]tokenizer.Token, len(paramValues))
+	for i, value := range paramValues {
+		tokens[i] = tokenizer.Token(value)
+	}
+	return h, tokens
+}
 
-	node := m.hosts
-	token, _, _ := tok.Next()
-	for node != nil && token != nil {
-		node = node.Child(token)
+// AppendHost is the same as Host except that the values that satisfied the expressions in the pattern are appended
+// to paramValues as strings. Callers can provide a slice with enough capacity to find a host without allocating.
+//
+// This method never returns nil.
+func (m *Mux[RH, EH]) AppendHost(hostname string, paramValues []string) (*host.Host[RH, EH], []string) {
+	h, paramValues, _ := m.ResolveHost(hostname, paramValues)
+	return h, paramValues
+}
 
-		if node != nil && node.Dynamic() {
-			if paramValues == nil {
-				paramValues = make([]tokenizer.Token, 0, 1)
+// ResolveHost is the same as AppendHost except that errors returned by the HostResolver are returned along with
+// the default host. In strict host mode ErrUnknownHost is returned if the default host does not answer to the
+// hostname.
+//
+// This method never returns a nil host.
+func (m *Mux[RH, EH]) ResolveHost(hostname string, paramValues []string) (*host.Host[RH, EH], []string, error) {
+	// Hostnames are case-insensitive and hosts answer on any port
+	key := hostKey(hostname)
+
+	if alias, ok := m.aliases.get(key); ok {
+		return alias.host, append(paramValues, alias.values...), nil
+	}
+
+	if m.ipHosts != nil {
+		// The key of an IPv6 address has no brackets, ParseHost handles the port and brackets of the hostname itself
+		if addr, ok := iptable.ParseHost(hostname); ok {
+			if entry := m.ipHosts.Find(addr); entry != nil {
+				return entry.Value(), paramValues, nil
 			}
-			paramValues = append(paramValues, token)
 		}
+	}
+
+	start := len(paramValues)
+
+	var h *host.Host[RH, EH]
+	if m.compiledHosts != nil {
+		h, paramValues = m.compiledHosts.FindDomain(key, paramValues)
+	} else {
+		var node routetree.Node[host.Host[RH, EH]]
+		node, paramValues = routetree.FindDomain(m.hosts, key, paramValues)
+		if node != nil {
+			h = node.Value()
+		}
+	}
 
-		token, _, _ = tok.Next()
+	if h != nil {
+		return h, paramValues, nil
 	}
 
-	if node == nil {
-		return m.defaultHost, paramValues
+	paramValues = paramValues[:start]
+
+	if m.resolver != nil {
+		h, err := m.resolver.resolveHost(key)
+		if err != nil {
+			return m.defaultHost, paramValues, err
+		}
+		if h != nil {
+			return h, paramValues, nil
+		}
 	}
 
-	h := node.Value()
-	if h == nil {
-		return m.defaultHost, paramValues
+	if m.strictHosts {
+		if _, ok := m.defaultNames[key]; !ok {
+			return m.defaultHost, paramValues, ErrUnknownHost
+		}
+	}
+
+	return m.defaultHost, paramValues, nil
+}
+
+// SetStrictHosts enables or disables strict host mode.
+//
+// By default any hostname that does not match a host is served by the default host, which makes routes
+// registered on the default host reachable under arbitrary hostnames. In strict host mode the default host only
+// answers to the hostnames passed to this method, on any port and in any casing, and ResolveHost returns
+// ErrUnknownHost for all other unknown hostnames. Host and AppendHost still return the default host.
+//
+// Each call replaces the hostnames of the previous call. SetStrictHosts is not safe to call while the mux is
+// serving requests.
+func (m *Mux[RH, EH]) SetStrictHosts(strict bool, defaultHostnames ...string) {
+	m.strictHosts = strict
+	m.defaultNames = nil
+
+	if len(defaultHostnames) > 0 {
+		m.defaultNames = make(map[string]struct{}, len(defaultHostnames))
+		for _, hostname := range defaultHostnames {
+			m.defaultNames[hostKey(hostname)] = struct{}{}
+		}
+	}
+}
+
+// StrictHosts returns true if strict host mode is enabled.
+func (m *Mux[RH, EH]) StrictHosts() bool {
+	return m.strictHosts
+}
+
+// Freeze compiles the host tree and the route trees of all hosts, including the default host, into immutable
+// matchers that are used transparently for all further lookups. Registering hosts or routes afterwards returns
+// ErrFrozen, or panics for methods that do not return errors such as Handle.
+//
+// Freeze should be called once all routes are registered and before the mux starts serving requests.
+// It is not safe to call while the mux is serving requests. Calling Freeze more than once has no effect.
+func (m *Mux[RH, EH]) Freeze() {
+	if m.compiledHosts != nil {
+		return
+	}
+
+	m.compiledHosts = routetree.Compile(m.hosts)
+	m.hosts = nil
+
+	m.defaultHost.Freeze()
+	for _, h := range m.compiledHosts.Values() {
+		h.Freeze()
+	}
+	if m.ipHosts != nil {
+		for _, h := range m.ipHosts.Values() {
+			h.Freeze()
+		}
 	}
-	return h, paramValues
+}
+
+// Frozen returns true if the mux has been frozen.
+func (m *Mux[RH, EH]) Frozen() bool {
+	return m.compiledHosts != nil
 }
 
 // Handle registers a event handler for a specific HTTP method and and path.
 func (m *Mux[RH, EH]) Handle(method, path string, handler RH) {
 	m.defaultHost.Handle(method, path, handler)
 }
+
+// HandleWhen registers a conditional event handler for a specific HTTP method and path on the default host that
+// only serves requests that satisfy the predicate. See host.Host.HandleWhen.
+func (m *Mux[RH, EH]) HandleWhen(method, path string, p predicate.Predicate, priority int, handler RH) {
+	m.defaultHost.HandleWhen(method, path, p, priority, handler)
+}
+
+// HandleMedia registers an event handler variant for the media types of the request for a specific HTTP method and
+// path on the default host. See host.Host.HandleMedia.
+func (m *Mux[RH, EH]) HandleMedia(method, path string, media resource.MediaType, handler RH) {
+	m.defaultHost.HandleMedia(method, path, media, handler)
+}
+
+// HandleSplit registers a split between weighted event handler variants for a specific HTTP method and path on the
+// default host. See host.Host.HandleSplit.
+func (m *Mux[RH, EH]) HandleSplit(method, path string, split *resource.Split[RH]) {
+	m.defaultHost.HandleSplit(method, path, split)
+}
+
+// Group creates a new route group on the default host for the path prefix.
+func (m *Mux[RH, EH]) Group(prefix string) *host.Group[RH, EH] {
+	return m.defaultHost.Group(prefix)
+}
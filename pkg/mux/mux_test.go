@@ -1,7 +1,10 @@
 package mux_test
 
 import (
+	"math"
 	"math/rand"
+	"runtime"
+	"slices"
 	"testing"
 
 	"proto.zip/studio/mux/pkg/host"
@@ -16,10 +19,44 @@ func TestNewMux(t *testing.T) {
 	}
 }
 
+func TestHostAllocations(t *testing.T) {
+	m := mux.New[any, any]()
+	m.NewHost("www.example.com")
+	m.NewHost("{db}.{region}.example.com")
+
+	buf := make([]string, 0, 4)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if h, _ := m.AppendHost("www.example.com", buf[:0]); h == m.DefaultHost() {
+			t.Error("Expected static host to match")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected static host lookup to not allocate, got %f allocations", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(100, func() {
+		if _, values := m.AppendHost("test.us.example.com", buf[:0]); len(values) != 2 {
+			t.Errorf("Expected 2 values, got %d", len(values))
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected parameterized host lookup with a buffer to not allocate, got %f allocations", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(100, func() {
+		m.AppendHost("test.us.example.com", nil)
+	})
+	if allocs > 2 {
+		t.Errorf("Expected parameterized host lookup without a buffer to allocate at most once per parameter, got %f allocations", allocs)
+	}
+}
+
 func BenchmarkDomain(b *testing.B) {
 	dn := "this.is.a.domain.for.benchmarking"
 	m := mux.New[any, any]()
 	m.NewHost(dn)
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
@@ -31,9 +68,8 @@ func BenchmarkDomain(b *testing.B) {
 	}
 }
 
-func BenchmarkDomain_Large(b *testing.B) {
-	m := mux.New[any, any]()
-
+// randomDomains returns at least count unique hostnames of the form sub1.sub2.example.tld.
+func randomDomains(count int) []string {
 	tlds := []string{
 		"com",
 		"net",
@@ -41,30 +77,46 @@ func BenchmarkDomain_Large(b *testing.B) {
 	}
 
 	charset := "abcdefghijklmnopqrstuvwxyz"
-	subDomains := make([]string, 200)
-	for i := range subDomains {
+	// Unique sub domains make every combination unique
+	subDomainCount := int(math.Ceil(math.Sqrt(float64(count) / float64(len(tlds)))))
+	subDomains := make([]string, 0, subDomainCount)
+	seen := make(map[string]struct{}, subDomainCount)
+	for len(subDomains) < subDomainCount {
 		subDomain := make([]byte, 15)
 		for j := 0; j < 15; j++ {
 			subDomain[j] = charset[rand.Intn(len(charset))]
 		}
-		subDomains[i] = string(subDomain)
-	}
 
-	size := len(subDomains) * len(subDomains) * len(tlds)
-	domainHandlers := make([]*host.Host[any, any], size)
-	domains := make([]string, size)
+		if _, ok := seen[string(subDomain)]; ok {
+			continue
+		}
+		seen[string(subDomain)] = struct{}{}
+		subDomains = append(subDomains, string(subDomain))
+	}
 
-	c := 0
+	domains := make([]string, 0, len(subDomains)*len(subDomains)*len(tlds))
 	for _, tld := range tlds {
 		for _, sub1 := range subDomains {
 			for _, sub2 := range subDomains {
-				domains[c] = sub1 + "." + sub2 + ".example." + tld
-				domainHandlers[c], _ = m.NewHost(domains[c])
-				c++
+				domains = append(domains, sub1+"."+sub2+".example."+tld)
 			}
 		}
 	}
 
+	return domains
+}
+
+func benchmarkDomainLookup(b *testing.B, count int) {
+	m := mux.New[any, any]()
+
+	domains := randomDomains(count)
+	domainHandlers := make([]*host.Host[any, any], len(domains))
+
+	for i, domain := range domains {
+		domainHandlers[i], _ = m.NewHost(domain)
+	}
+
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
@@ -82,3 +134,262 @@ func BenchmarkDomain_Large(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkDomain_Large(b *testing.B) {
+	benchmarkDomainLookup(b, 120000)
+}
+
+func BenchmarkDomain_Large1M(b *testing.B) {
+	benchmarkDomainLookup(b, 1000000)
+}
+
+// domainMemory is the mux built by the last run of benchmarkDomainMemory. It is kept so that the mux is built once
+// per benchmark instead of once per run of the benchmark function.
+var domainMemory struct {
+	shared  bool
+	domains []string
+	m       *mux.Mux[any, any]
+	perHost float64
+}
+
+// benchmarkDomainMemory measures the memory used by a mux with a million hosts that each serve the same routes.
+// The mux is built once before the timer starts, the iterations only look up hosts.
+func benchmarkDomainMemory(b *testing.B, shared bool) {
+	if domainMemory.m == nil || domainMemory.shared != shared {
+		domainMemory.m = nil
+		buildDomainMemory(b, shared)
+	}
+
+	domains, m := domainMemory.domains, domainMemory.m
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if h, _ := m.Host(domains[n%len(domains)]); h == nil {
+			b.Fatalf("Expected host %s to exist", domains[n%len(domains)])
+		}
+	}
+
+	b.StopTimer()
+	b.ReportMetric(domainMemory.perHost, "B/host")
+}
+
+// buildDomainMemory builds the mux of benchmarkDomainMemory and measures its memory.
+func buildDomainMemory(b *testing.B, shared bool) {
+	domains := randomDomains(1000000)
+
+	template := host.New[any, any]()
+	template.Handle("GET", "/docs/{id}", nil)
+	template.Handle("GET", "/docs/{id}/revisions/{revision}", nil)
+
+	var before, after runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	m := mux.New[any, any]()
+	for _, domain := range domains {
+		if shared {
+			if _, err := m.NewSharedHost(domain, template); err != nil {
+				b.Fatalf("Unexpected error creating host: %s", err)
+			}
+		} else {
+			h, err := m.NewHost(domain)
+			if err != nil {
+				b.Fatalf("Unexpected error creating host: %s", err)
+			}
+			h.Handle("GET", "/docs/{id}", nil)
+			h.Handle("GET", "/docs/{id}/revisions/{revision}", nil)
+		}
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	domainMemory.shared = shared
+	domainMemory.domains = domains
+	domainMemory.m = m
+	// HeapAlloc may shrink between the reads, so subtract as signed values
+	domainMemory.perHost = float64(int64(after.HeapAlloc)-int64(before.HeapAlloc)) / float64(len(domains))
+}
+
+func BenchmarkDomainMemory_Shared(b *testing.B) {
+	benchmarkDomainMemory(b, true)
+}
+
+func BenchmarkDomainMemory_Unshared(b *testing.B) {
+	benchmarkDomainMemory(b, false)
+}
+
+func TestNewSharedHost(t *testing.T) {
+	m := mux.New[any, any]()
+
+	template := host.New[any, any]()
+	template.Handle("GET", "/docs/{id}", nil)
+
+	a, err := m.NewSharedHost("a.example.com", template)
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	b, err := m.NewSharedHost("{tenant}.example.org", template)
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	if !template.Frozen() || !a.Frozen() || !b.Frozen() {
+		t.Error("Expected shared hosts to be frozen")
+	}
+
+	if found, _ := m.Host("a.example.com"); found != a || found.Pattern() != "a.example.com" {
+		t.Error("Expected to find the first shared host")
+	}
+
+	found, values := m.AppendHost("test.example.org", nil)
+	if found != b || len(values) != 1 || values[0] != "test" || found.ParamNames()[0] != "tenant" {
+		t.Errorf("Expected to find the second shared host with value 'test', got %v", values)
+	}
+
+	if r, _ := found.AppendResource("/docs/123", nil); r == nil {
+		t.Error("Expected shared host to find resource")
+	}
+
+	if again, err := m.NewSharedHost("a.example.com", template); err != nil || again != a {
+		t.Errorf("Expected existing shared host, got error %v", err)
+	}
+
+	if _, err := m.NewHost("c.example.com"); err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	if _, err := m.NewSharedHost("c.example.com", template); err != mux.ErrHostExists {
+		t.Errorf("Expected ErrHostExists, got %v", err)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	m := mux.New[any, any]()
+
+	h, err := m.NewHost("{db}.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	h.Handle("GET", "/docs/{id}", nil)
+	m.Handle("GET", "/about", nil)
+
+	m.Freeze()
+
+	if !m.Frozen() || !h.Frozen() || !m.DefaultHost().Frozen() {
+		t.Error("Expected mux and hosts to be frozen")
+	}
+
+	if found, values := m.AppendHost("test.example.com", nil); found != h || len(values) != 1 || values[0] != "test" {
+		t.Errorf("Expected frozen mux to find host with value 'test', got %v", values)
+	}
+
+	if r, values := h.AppendResource("/docs/123", nil); r == nil || len(values) != 1 || values[0] != "123" {
+		t.Errorf("Expected frozen host to find resource with value '123', got %v", values)
+	}
+
+	if r, _ := m.DefaultHost().AppendResource("/about", nil); r == nil {
+		t.Error("Expected frozen default host to find resource")
+	}
+
+	if _, err := m.NewHost("other.example.com"); err != mux.ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+
+	if _, _, err := h.NewResource([]byte("/other")); err != mux.ErrFrozen {
+		t.Errorf("Expected ErrFrozen, got %v", err)
+	}
+
+	defer func() {
+		if err := recover(); err != mux.ErrFrozen {
+			t.Errorf("Expected Handle to panic with ErrFrozen, got %v", err)
+		}
+	}()
+	m.Handle("GET", "/other", nil)
+}
+
+func TestIPHosts(t *testing.T) {
+	m := mux.New[any, any]()
+
+	hosts := make(map[string]*host.Host[any, any])
+	for _, pattern := range []string{"10.0.0.0/8", "10.0.0.5", "[::1]", "fd00::/8"} {
+		h, err := m.NewHost(pattern)
+		if err != nil {
+			t.Fatalf("Unexpected error creating host '%s': %s", pattern, err)
+		}
+		hosts[pattern] = h
+	}
+
+	if again, _ := m.NewHost("10.0.0.0/8"); again != hosts["10.0.0.0/8"] {
+		t.Error("Expected existing IP host to be returned")
+	}
+
+	m.Freeze()
+
+	if !hosts["10.0.0.0/8"].Frozen() {
+		t.Error("Expected IP hosts to be frozen")
+	}
+
+	tests := []struct {
+		hostname string
+		expected *host.Host[any, any]
+	}{
+		{"10.0.0.5", hosts["10.0.0.5"]},
+		{"10.0.0.5:8080", hosts["10.0.0.5"]},
+		{"10.20.30.40", hosts["10.0.0.0/8"]},
+		{"[::1]:8080", hosts["[::1]"]},
+		{"[fd00::1]", hosts["fd00::/8"]},
+		{"192.168.0.1", m.DefaultHost()},
+		{"example.com", m.DefaultHost()},
+	}
+
+	for _, test := range tests {
+		if h, _ := m.Host(test.hostname); h != test.expected {
+			t.Errorf("Expected '%s' to match host '%s', got '%s'", test.hostname, test.expected.Pattern(), h.Pattern())
+		}
+	}
+}
+
+func TestPartialLabelHosts(t *testing.T) {
+	m := mux.New[any, any]()
+
+	hosts := make(map[string]*host.Host[any, any])
+	for _, pattern := range []string{"{tenant}.example.com", "{tenant}-api.example.com", "{tenant}-eu-api.example.com", "www-api.example.com", "pr-{number}.preview.example.com"} {
+		h, err := m.NewHost(pattern)
+		if err != nil {
+			t.Fatalf("Unexpected error creating host '%s': %s", pattern, err)
+		}
+		hosts[pattern] = h
+	}
+
+	tests := []struct {
+		hostname string
+		pattern  string
+		values   []string
+	}{
+		{"www-api.example.com", "www-api.example.com", nil},
+		{"acme-api.example.com", "{tenant}-api.example.com", []string{"acme"}},
+		{"acme-eu-api.example.com", "{tenant}-eu-api.example.com", []string{"acme"}},
+		{"acme.example.com", "{tenant}.example.com", []string{"acme"}},
+		{"pr-42.preview.example.com", "pr-{number}.preview.example.com", []string{"42"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		for _, test := range tests {
+			h, values := m.AppendHost(test.hostname, nil)
+			if h != hosts[test.pattern] || !slices.Equal(values, test.values) {
+				t.Errorf("Expected '%s' to match '%s' with %v, got '%s' with %v", test.hostname, test.pattern, test.values, h.Pattern(), values)
+			}
+		}
+
+		if names := hosts["pr-{number}.preview.example.com"].ParamNames(); !slices.Equal(names, []string{"number"}) {
+			t.Errorf("Expected param names [number], got %v", names)
+		}
+
+		m.Freeze()
+	}
+}
@@ -0,0 +1,109 @@
+package mux
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/muxcontext"
+)
+
+// requestScheme returns the scheme the client used to make the request, "http" or "https".
+// The scheme reported by a trusted proxy takes precedence over the scheme of the connection.
+func requestScheme(r *http.Request, rc *muxcontext.RouteContext) string {
+	switch {
+	case rc.Forwarded.Proto == "https":
+		return "https"
+	case rc.Forwarded.Proto != "":
+		return "http"
+	case r.TLS != nil:
+		return "https"
+	default:
+		return "http"
+	}
+}
+
+// hostnameWithoutPort returns the hostname of a host header value without the port, if any.
+func hostnameWithoutPort(hostport string) string {
+	// SplitHostPort allocates an error for values without a port
+	if strings.IndexByte(hostport, ':') < 0 {
+		return hostport
+	}
+
+	if hostname, _, err := net.SplitHostPort(hostport); err == nil {
+		return hostname
+	}
+	return hostport
+}
+
+// hostKey returns the lower-case hostname of a host header value without the port. It is used as the key of exact
+// hostnames, such as aliases, so they match on any port and in any casing. It only allocates if the hostname has
+// upper-case letters.
+func hostKey(hostport string) string {
+	return strings.ToLower(hostnameWithoutPort(hostport))
+}
+
+// redirectStatus returns the status code used for policy redirects of the request.
+func redirectStatus(policy *host.Policy, r *http.Request) int {
+	if policy.RedirectStatus != 0 {
+		return policy.RedirectStatus
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return http.StatusMovedPermanently
+	}
+	return http.StatusPermanentRedirect
+}
+
+// applyPolicy enforces the policy of the host on a request for the hostname.
+//
+// If the request was made to a hostname other than the canonical hostname or without HTTPS when it is required, a
+// redirect is served and true is returned. Otherwise the Strict-Transport-Security header is added to HTTPS
+// responses when enabled and false is returned.
+func applyPolicy(policy *host.Policy, hostname string, rc *muxcontext.RouteContext, w http.ResponseWriter, r *http.Request) bool {
+	scheme := requestScheme(r, rc)
+	targetScheme := scheme
+	targetHost := hostname
+
+	if policy.RequireHTTPS && scheme != "https" {
+		targetScheme = "https"
+		// The port of a plain HTTP request does not apply to HTTPS
+		targetHost = hostnameWithoutPort(hostname)
+	}
+
+	if policy.CanonicalHost != "" && hostnameWithoutPort(hostname) != policy.CanonicalHost && hostname != policy.CanonicalHost {
+		targetHost = policy.CanonicalHost
+	}
+
+	if targetScheme != scheme || targetHost != hostname {
+		location := targetScheme + "://" + targetHost + rc.Forwarded.Prefix + r.URL.RequestURI()
+		http.Redirect(w, r, location, redirectStatus(policy, r))
+		return true
+	}
+
+	if scheme == "https" {
+		if hsts := policy.HSTSHeader(); hsts != "" {
+			w.Header().Set("Strict-Transport-Security", hsts)
+		}
+	}
+
+	return false
+}
+
+// applyPathCase redirects requests to a case-insensitive host whose path does not have the casing of the pattern of
+// the resource it matched. The path is the request path without the locale prefix, if any, which is kept in the
+// redirect. It returns true if a redirect was served.
+func applyPathCase[RH any, EH any](policy *host.Policy, h *host.Host[RH, EH], prefix, path string, rc *muxcontext.RouteContext, w http.ResponseWriter, r *http.Request) bool {
+	canonical := h.CanonicalPath(path)
+	if canonical == path {
+		return false
+	}
+
+	target := *r.URL
+	target.Path = prefix + canonical
+	target.RawPath = ""
+
+	http.Redirect(w, r, rc.Forwarded.Prefix+target.RequestURI(), redirectStatus(policy, r))
+	return true
+}
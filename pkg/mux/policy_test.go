@@ -0,0 +1,152 @@
+package mux_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/mux"
+)
+
+func TestServeHTTPPolicy(t *testing.T) {
+	m := mux.NewHTTP()
+
+	policy := &host.Policy{
+		CanonicalHost:         "example.com",
+		RequireHTTPS:          true,
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+	}
+
+	for _, pattern := range []string{"example.com", "www.example.com"} {
+		h, err := m.NewHost(pattern)
+		if err != nil {
+			t.Fatalf("Unexpected error creating host: %s", err)
+		}
+		h.Policy = policy
+		h.Handle(http.MethodGet, "/docs", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("docs"))
+		}))
+	}
+
+	tests := []struct {
+		method   string
+		url      string
+		tls      bool
+		status   int
+		location string
+	}{
+		{http.MethodGet, "http://example.com/docs?a=1", false, http.StatusMovedPermanently, "https://example.com/docs?a=1"},
+		{http.MethodGet, "https://www.example.com/docs", true, http.StatusMovedPermanently, "https://example.com/docs"},
+		{http.MethodPost, "http://www.example.com/docs", false, http.StatusPermanentRedirect, "https://example.com/docs"},
+		{http.MethodGet, "https://example.com/docs", true, http.StatusOK, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, nil)
+		if !test.tls {
+			r.TLS = nil
+		} else if r.TLS == nil {
+			r.TLS = &tls.ConnectionState{}
+		}
+
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("Expected %s '%s' to return %d, got %d", test.method, test.url, test.status, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != test.location {
+			t.Errorf("Expected %s '%s' to redirect to '%s', got '%s'", test.method, test.url, test.location, location)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/docs", nil)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if hsts := w.Header().Get("Strict-Transport-Security"); hsts != "max-age=31536000; includeSubDomains" {
+		t.Errorf("Unexpected Strict-Transport-Security header '%s'", hsts)
+	}
+}
+
+func TestServeHTTPPolicyForwardedProto(t *testing.T) {
+	m := mux.NewHTTP()
+	m.DefaultHost().Policy = &host.Policy{RequireHTTPS: true}
+	m.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {})
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Prefix", "/app")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("Expected untrusted X-Forwarded-Proto to be ignored, got %d", w.Code)
+	}
+
+	// httptest requests are made from 192.0.2.1
+	m.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected trusted X-Forwarded-Proto to be used, got %d", w.Code)
+	}
+
+	r.Header.Set("X-Forwarded-Proto", "http")
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if location := w.Header().Get("Location"); location != "https://localhost/app/" {
+		t.Errorf("Expected redirect to include the forwarded prefix, got '%s'", location)
+	}
+}
+
+func TestServeHTTPRedirectPathCase(t *testing.T) {
+	m := mux.NewHTTP()
+
+	h, err := m.NewHost("example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+	if err := h.SetCaseInsensitive(true); err != nil {
+		t.Fatalf("Unexpected error enabling case-insensitive paths: %s", err)
+	}
+	h.Policy = &host.Policy{RedirectPathCase: true}
+	h.Handle(http.MethodGet, "/Docs/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("docs"))
+	}))
+
+	tests := []struct {
+		method   string
+		url      string
+		status   int
+		location string
+	}{
+		{http.MethodGet, "http://example.com/docs/ABC?a=1", http.StatusMovedPermanently, "/Docs/ABC?a=1"},
+		{http.MethodPost, "http://example.com/DOCS/abc", http.StatusPermanentRedirect, "/Docs/abc"},
+		{http.MethodGet, "http://example.com/Docs/abc", http.StatusOK, ""},
+		{http.MethodGet, "http://example.com/missing", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, nil)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("Expected %s '%s' to return %d, got %d", test.method, test.url, test.status, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != test.location {
+			t.Errorf("Expected %s '%s' to redirect to '%s', got '%s'", test.method, test.url, test.location, location)
+		}
+	}
+}
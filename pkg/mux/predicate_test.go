@@ -0,0 +1,67 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/muxcontext"
+	"proto.zip/studio/mux/pkg/predicate"
+)
+
+func TestServeHTTPPredicates(t *testing.T) {
+	m := mux.NewHTTP()
+
+	respond := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + muxcontext.PathParam(r.Context(), "id")))
+		}
+	}
+
+	m.Handle(http.MethodGet, "/reports/{id}", respond("default"))
+	m.HandleWhen(http.MethodGet, "/reports/{id}", predicate.QueryValue("format", "csv"), 0, respond("csv"))
+	m.HandleWhen(http.MethodGet, "/reports/{id}", predicate.Header("Accept-Version", "2"), 10, respond("v2"))
+	m.HandleWhen(http.MethodGet, "/reports/{id}", predicate.Cookie("beta"), 0, respond("beta"))
+
+	// Only conditional handlers
+	m.HandleWhen(http.MethodPost, "/reports/{id}", predicate.Header("Accept-Version", "2"), 0, respond("post"))
+
+	tests := []struct {
+		method  string
+		url     string
+		version string
+		cookie  bool
+		status  int
+		body    string
+	}{
+		{http.MethodGet, "/reports/1", "", false, http.StatusOK, "default:1"},
+		{http.MethodGet, "/reports/1?format=csv", "", false, http.StatusOK, "csv:1"},
+		{http.MethodGet, "/reports/1?format=csv", "2", false, http.StatusOK, "v2:1"},
+		{http.MethodGet, "/reports/1?format=csv", "", true, http.StatusOK, "csv:1"},
+		{http.MethodGet, "/reports/1", "", true, http.StatusOK, "beta:1"},
+		{http.MethodPost, "/reports/1", "2", false, http.StatusOK, "post:1"},
+		{http.MethodPost, "/reports/1", "", false, http.StatusNotFound, ""},
+		{http.MethodDelete, "/reports/1", "", false, http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, nil)
+		if test.version != "" {
+			r.Header.Set("Accept-Version", test.version)
+		}
+		if test.cookie {
+			r.AddCookie(&http.Cookie{Name: "beta", Value: "1"})
+		}
+
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("Expected %s '%s' to return %d, got %d", test.method, test.url, test.status, w.Code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("Expected %s '%s' to return '%s', got '%s'", test.method, test.url, test.body, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"proto.zip/studio/mux/internal/cache"
+	"proto.zip/studio/mux/pkg/host"
+)
+
+// DefaultHostCacheSize is the number of hostnames cached when HostCacheOptions does not specify a size.
+const DefaultHostCacheSize = 10000
+
+// HostResolver is called when no registered host matches a hostname. It can be used to load hosts on demand,
+// for example from a database, instead of registering every host on startup.
+//
+// The resolver is called with the lower-case hostname without the port, which is also the key its result is
+// cached under. It returns the host for the hostname or nil if the hostname is unknown, in which case the default host
+// is used. Returned hosts must not have pattern parameters since there is no pattern to match them against,
+// ErrResolvedHostParams is returned for such hosts and the result is not cached.
+// Hosts created with host.NewShared are a good fit since many resolved hosts can share the same routes.
+//
+// If the resolver returns an error the result is not cached. Host and AppendHost fall back to the default host
+// while ResolveHost and HttpMux return the error so it can be served by the error handler.
+//
+// The resolver may be called concurrently, including for the same hostname.
+type HostResolver[RequestHandlerType any, ErrorHandlerType any] func(hostname string) (*host.Host[RequestHandlerType, ErrorHandlerType], error)
+
+// HostCacheOptions configures how the results of a HostResolver are cached.
+type HostCacheOptions struct {
+	Size        int           // Size is the maximum number of hostnames cached. Zero uses DefaultHostCacheSize.
+	TTL         time.Duration // TTL is how long a resolved host is cached. Zero caches the host until it is evicted.
+	NegativeTTL time.Duration // NegativeTTL is how long an unknown hostname is cached. Zero disables negative caching.
+}
+
+// ErrResolvedHostParams is returned when a HostResolver returns a host with pattern parameters.
+var ErrResolvedHostParams = errors.New("resolved host must not have pattern parameters")
+
+// hostResolver holds a HostResolver and the cache of its results. Unknown hostnames are cached as nil hosts.
+type hostResolver[RH any, EH any] struct {
+	resolve HostResolver[RH, EH]
+	options HostCacheOptions
+	cache   *cache.LRU[string, *host.Host[RH, EH]]
+}
+
+// SetHostResolver sets the resolver that is called when no registered host matches a hostname and configures how
+// its results are cached. Registered hosts always take precedence over resolved hosts.
+//
+// Passing a nil resolver removes the resolver and its cache. SetHostResolver is not safe to call while the mux is
+// serving requests.
+func (m *Mux[RH, EH]) SetHostResolver(resolver HostResolver[RH, EH], options HostCacheOptions) {
+	if resolver == nil {
+		m.resolver = nil
+		return
+	}
+
+	if options.Size <= 0 {
+		options.Size = DefaultHostCacheSize
+	}
+
+	m.resolver = &hostResolver[RH, EH]{
+		resolve: resolver,
+		options: options,
+		cache:   cache.NewLRU[string, *host.Host[RH, EH]](options.Size),
+	}
+}
+
+// InvalidateHost removes the cached result for the hostname so the next lookup calls the resolver again.
+// It has no effect if there is no resolver or the hostname is not cached.
+func (m *Mux[RH, EH]) InvalidateHost(hostname string) {
+	if m.resolver != nil {
+		m.resolver.cache.Remove(hostKey(hostname))
+	}
+}
+
+// resolveHost returns the host for the key of a hostname, see hostKey, from the cache or the resolver.
+// Nil is returned if the hostname is unknown.
+func (r *hostResolver[RH, EH]) resolveHost(hostname string) (*host.Host[RH, EH], error) {
+	now := time.Now()
+
+	if h, ok := r.cache.Get(hostname, now); ok {
+		return h, nil
+	}
+
+	h, err := r.resolve(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	if h != nil && len(h.ParamNames()) > 0 {
+		return nil, fmt.Errorf("%w: %s resolved to %s", ErrResolvedHostParams, hostname, h.Pattern())
+	}
+
+	if h != nil {
+		r.cache.Add(hostname, h, r.options.TTL, now)
+	} else if r.options.NegativeTTL > 0 {
+		r.cache.Add(hostname, nil, r.options.NegativeTTL, now)
+	}
+
+	return h, nil
+}
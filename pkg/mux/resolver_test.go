@@ -0,0 +1,159 @@
+package mux_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"proto.zip/studio/mux/pkg/host"
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/tokenizer"
+)
+
+// stubRegistry is a HostResolver backed by a map that counts how often each hostname is resolved.
+type stubRegistry struct {
+	hosts map[string]*host.Host[any, any]
+	calls map[string]int
+}
+
+func newStubRegistry(hostnames ...string) *stubRegistry {
+	template := host.New[any, any]()
+	template.Handle("GET", "/docs/{id}", nil)
+
+	registry := &stubRegistry{
+		hosts: make(map[string]*host.Host[any, any]),
+		calls: make(map[string]int),
+	}
+
+	for _, hostname := range hostnames {
+		registry.hosts[hostname] = host.NewShared(template, hostname, nil)
+	}
+
+	return registry
+}
+
+func (s *stubRegistry) resolve(hostname string) (*host.Host[any, any], error) {
+	s.calls[hostname]++
+	return s.hosts[hostname], nil
+}
+
+func TestHostResolver(t *testing.T) {
+	registry := newStubRegistry("a.tenant.com", "b.tenant.com")
+
+	m := mux.New[any, any]()
+	m.SetHostResolver(registry.resolve, mux.HostCacheOptions{})
+
+	registered, err := m.NewHost("a.tenant.com")
+	if err != nil {
+		t.Fatalf("Unexpected error creating host: %s", err)
+	}
+
+	if h, _ := m.Host("a.tenant.com"); h != registered {
+		t.Error("Expected registered host to take precedence")
+	}
+
+	for _, hostname := range []string{"b.tenant.com", "B.Tenant.com:8443"} {
+		if h, _ := m.Host(hostname); h != registry.hosts["b.tenant.com"] {
+			t.Errorf("Expected resolved host for %s", hostname)
+		}
+	}
+
+	if registry.calls["b.tenant.com"] != 1 {
+		t.Errorf("Expected resolved host to be cached, resolver called %d times", registry.calls["b.tenant.com"])
+	}
+
+	m.InvalidateHost("B.tenant.com")
+	m.Host("b.tenant.com")
+
+	if registry.calls["b.tenant.com"] != 2 {
+		t.Errorf("Expected invalidated host to be resolved again, resolver called %d times", registry.calls["b.tenant.com"])
+	}
+
+	for i := 0; i < 2; i++ {
+		if h, _ := m.Host("unknown.tenant.com"); h != m.DefaultHost() {
+			t.Error("Expected default host for unknown host")
+		}
+	}
+
+	if registry.calls["unknown.tenant.com"] != 2 {
+		t.Errorf("Expected unknown host to not be cached, resolver called %d times", registry.calls["unknown.tenant.com"])
+	}
+}
+
+func TestHostResolverNegativeCache(t *testing.T) {
+	registry := newStubRegistry()
+
+	m := mux.New[any, any]()
+	m.SetHostResolver(registry.resolve, mux.HostCacheOptions{NegativeTTL: time.Minute})
+
+	m.Host("unknown.tenant.com")
+	m.Host("unknown.tenant.com")
+
+	if registry.calls["unknown.tenant.com"] != 1 {
+		t.Errorf("Expected unknown host to be cached, resolver called %d times", registry.calls["unknown.tenant.com"])
+	}
+}
+
+func TestHostResolverCacheSize(t *testing.T) {
+	registry := newStubRegistry("a.tenant.com", "b.tenant.com")
+
+	m := mux.New[any, any]()
+	m.SetHostResolver(registry.resolve, mux.HostCacheOptions{Size: 1})
+
+	m.Host("a.tenant.com")
+	m.Host("b.tenant.com")
+	m.Host("a.tenant.com")
+
+	if registry.calls["a.tenant.com"] != 2 {
+		t.Errorf("Expected host to be evicted, resolver called %d times", registry.calls["a.tenant.com"])
+	}
+}
+
+func TestServeHTTPHostResolverError(t *testing.T) {
+	m := mux.NewHTTP()
+	m.DefaultHost().ErrorHandler = errorHandlerWithBody("default")
+	m.SetHostResolver(func(hostname string) (*host.Host[http.Handler, mux.HttpErrorHandler], error) {
+		return nil, mux.NewHttpError(http.StatusServiceUnavailable)
+	}, mux.HostCacheOptions{})
+
+	m.HandleFunc(http.MethodGet, "/", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected handler to not be called")
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://unknown.tenant.com/", nil))
+
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "default" {
+		t.Errorf("Expected status 503 from the default error handler, got %d '%s'", w.Code, w.Body.String())
+	}
+
+	if _, _, err := m.ResolveHost("unknown.tenant.com", nil); !errors.Is(err, mux.NewHttpError(http.StatusServiceUnavailable)) {
+		t.Errorf("Expected resolver error, got %v", err)
+	}
+}
+
+func TestHostResolverParams(t *testing.T) {
+	calls := 0
+	m := mux.NewHTTP()
+	m.SetHostResolver(func(hostname string) (*host.Host[http.Handler, mux.HttpErrorHandler], error) {
+		calls++
+		return host.NewWithPattern[http.Handler, mux.HttpErrorHandler]("{tenant}.example.com", []tokenizer.Token{tokenizer.Token("tenant")}), nil
+	}, mux.HostCacheOptions{})
+
+	if h, _, err := m.ResolveHost("a.tenant.com", nil); h != m.DefaultHost() || !errors.Is(err, mux.ErrResolvedHostParams) {
+		t.Errorf("Expected the default host and ErrResolvedHostParams, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://a.tenant.com/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected host with parameters to not be cached, resolver called %d times", calls)
+	}
+}
@@ -0,0 +1,41 @@
+package mux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+)
+
+func TestServeHTTPLiteralAndWildcardSiblings(t *testing.T) {
+	paths := []string{"/about", "/{id}", "/id"}
+	orders := [][]int{{0, 1, 2}, {1, 0, 2}, {2, 1, 0}, {1, 2, 0}}
+
+	for _, order := range orders {
+		m := mux.NewHTTP()
+
+		for _, i := range order {
+			path := paths[i]
+			m.HandleFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(path))
+			})
+		}
+
+		for request, expected := range map[string]string{
+			"/about": "/about",
+			"/id":    "/id",
+			"/123":   "/{id}",
+		} {
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, request, nil))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status 200 for %s with order %v, got %d", request, order, w.Code)
+			}
+			if w.Body.String() != expected {
+				t.Errorf("Expected %s to be served by '%s' with order %v, got '%s'", request, expected, order, w.Body.String())
+			}
+		}
+	}
+}
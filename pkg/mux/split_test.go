@@ -0,0 +1,186 @@
+package mux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/mux"
+	"proto.zip/studio/mux/pkg/muxcontext"
+	"proto.zip/studio/mux/pkg/predicate"
+	"proto.zip/studio/mux/pkg/resource"
+)
+
+func TestServeHTTPSplit(t *testing.T) {
+	m := mux.NewHTTP()
+
+	respond := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + muxcontext.Variant(r.Context())))
+		})
+	}
+
+	split := resource.NewSplit(resource.Sticky{Cookie: "session", Header: "X-User-Id"},
+		resource.Variant[http.Handler]{Name: "stable", Weight: 90, Handler: respond("v1")},
+		resource.Variant[http.Handler]{Name: "canary", Weight: 10, Handler: respond("v2")},
+	)
+
+	m.HandleSplit(http.MethodGet, "/checkout", split)
+	m.HandleWhen(http.MethodGet, "/checkout", predicate.Header("X-Debug", "1"), 0, respond("debug"))
+
+	serve := func(cookie, header string) string {
+		r := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "session", Value: cookie})
+		}
+		if header != "" {
+			r.Header.Set("X-User-Id", header)
+		}
+
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		return w.Body.String()
+	}
+
+	// Requests with the same sticky value are served by the same variant
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		session := fmt.Sprintf("session-%d", i)
+		body := serve(session, "")
+		if again := serve(session, ""); again != body {
+			t.Errorf("Expected session %s to be served by '%s' again, got '%s'", session, body, again)
+		}
+		counts[body]++
+	}
+
+	if counts["v1:stable"]+counts["v2:canary"] != 1000 {
+		t.Fatalf("Expected only the stable and canary variants, got %v", counts)
+	}
+	if counts["v2:canary"] < 50 || counts["v2:canary"] > 150 {
+		t.Errorf("Expected about 100 requests to the canary, got %d", counts["v2:canary"])
+	}
+
+	// The cookie takes precedence over the header
+	if serve("session-1", "a") != serve("session-1", "b") {
+		t.Errorf("Expected the cookie to assign the variant when the header differs")
+	}
+
+	// Conditional handlers are tried first and have no variant
+	r := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+	r.Header.Set("X-Debug", "1")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	if w.Body.String() != "debug:" {
+		t.Errorf("Expected the conditional handler, got '%s'", w.Body.String())
+	}
+
+	// Weights can be changed while serving
+	if err := split.SetWeights(map[string]int{"stable": 0}); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	for i := 0; i < 20; i++ {
+		if body := serve("", ""); body != "v2:canary" {
+			t.Errorf("Expected all requests to be served by the canary, got '%s'", body)
+		}
+	}
+
+	if weights := split.Weights(); weights["stable"] != 0 || weights["canary"] != 10 {
+		t.Errorf("Expected weights stable=0 and canary=10, got %v", weights)
+	}
+
+	if err := split.SetWeights(map[string]int{"canary": 0}); err == nil {
+		t.Errorf("Expected an error when all weights are zero")
+	}
+	if err := split.SetWeights(map[string]int{"beta": 1}); err == nil {
+		t.Errorf("Expected an error for an unknown variant")
+	}
+	if err := split.SetWeights(map[string]int{"stable": -1}); err == nil {
+		t.Errorf("Expected an error for a negative weight")
+	}
+}
+
+func TestServeHTTPSplitHostParam(t *testing.T) {
+	m := mux.NewHTTP()
+
+	h, err := m.NewHost("{tenant}.example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	respond := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(muxcontext.Variant(r.Context())))
+	}
+
+	split := resource.NewSplit(resource.Sticky{HostParam: "tenant"},
+		resource.Variant[http.Handler]{Name: "a", Weight: 1, Handler: http.HandlerFunc(respond)},
+		resource.Variant[http.Handler]{Name: "b", Weight: 1, Handler: http.HandlerFunc(respond)},
+	)
+	h.HandleSplit(http.MethodGet, "/", split)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		hostname := fmt.Sprintf("tenant%d.example.com", i)
+
+		var first string
+		for j := 0; j < 5; j++ {
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://"+hostname+"/", nil))
+
+			if j == 0 {
+				first = w.Body.String()
+			} else if w.Body.String() != first {
+				t.Errorf("Expected %s to be served by '%s', got '%s'", hostname, first, w.Body.String())
+			}
+		}
+		seen[first] = true
+	}
+
+	if !seen["a"] || !seen["b"] {
+		t.Errorf("Expected tenants to be assigned to both variants, got %v", seen)
+	}
+}
+
+func TestResourceSplitRegistration(t *testing.T) {
+	split := resource.NewSplit(resource.Sticky{}, resource.Variant[http.Handler]{Name: "only", Weight: 1})
+
+	expectPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected %s to panic", name)
+			}
+		}()
+		fn()
+	}
+
+	res := resource.New[http.Handler]()
+	res.HandleMethodSplit(http.MethodGet, split)
+
+	expectPanic("HandleMethod after a split", func() {
+		res.HandleMethod(http.MethodGet, http.NotFoundHandler())
+	})
+	expectPanic("a second split", func() {
+		res.HandleMethodSplit(http.MethodGet, split)
+	})
+	expectPanic("a split without variants", func() {
+		resource.NewSplit[http.Handler](resource.Sticky{})
+	})
+	expectPanic("duplicate variant names", func() {
+		resource.NewSplit(resource.Sticky{},
+			resource.Variant[http.Handler]{Name: "a", Weight: 1},
+			resource.Variant[http.Handler]{Name: "a", Weight: 1},
+		)
+	})
+
+	if !res.HasMethod(http.MethodGet) || len(res.Methods()) != 1 {
+		t.Errorf("Expected the split to register GET, got %v", res.Methods())
+	}
+	if res.Split(http.MethodGet) != split {
+		t.Errorf("Expected Split to return the registered split")
+	}
+}
@@ -0,0 +1,23 @@
+package muxcontext
+
+import (
+	"context"
+)
+
+// ForwardedRequest holds the values of the original client request as reported by a trusted proxy through the
+// Forwarded or X-Forwarded-* headers. Fields the proxy did not report are empty.
+type ForwardedRequest struct {
+	For    string // For is the address of the client.
+	Host   string // Host is the host requested by the client.
+	Proto  string // Proto is the scheme requested by the client, e.g. "https".
+	Prefix string // Prefix is the path prefix the proxy removed before forwarding the request.
+}
+
+// Forwarded retrieves the original client request values reported by a trusted proxy from the given context.
+// It returns nil if the request was not forwarded by a trusted proxy.
+func Forwarded(ctx context.Context) *ForwardedRequest {
+	if rc := Route(ctx); rc != nil && rc.Forwarded != (ForwardedRequest{}) {
+		return &rc.Forwarded
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package muxcontext
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+)
+
+// WithLocale associates the given locale with the parent context and returns the resulting context.
+func WithLocale(parent context.Context, locale language.Tag) context.Context {
+	return withRouteUpdate(parent, func(rc *RouteContext) {
+		rc.Locale = locale
+	})
+}
+
+// Locale retrieves the locale of the request from the given context, taken from the /{lang} prefix of the path on
+// hosts with locales.
+// It returns language.Und if the context is nil or if no locale is associated with it.
+func Locale(ctx context.Context) language.Tag {
+	if rc := Route(ctx); rc != nil {
+		return rc.Locale
+	}
+	return language.Und
+}
@@ -0,0 +1,22 @@
+package muxcontext
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger associates the given logger with the parent context and returns the resulting context.
+func WithLogger(parent context.Context, logger *slog.Logger) context.Context {
+	return withRouteUpdate(parent, func(rc *RouteContext) {
+		rc.Logger = logger
+	})
+}
+
+// Logger retrieves the associated logger from the given context.
+// It returns nil if the context is nil or if no logger is associated with it.
+func Logger(ctx context.Context) *slog.Logger {
+	if rc := Route(ctx); rc != nil {
+		return rc.Logger
+	}
+	return nil
+}
@@ -9,29 +9,26 @@ import (
 	"proto.zip/studio/mux/pkg/resource"
 )
 
-var hostContextKey int
-var resourceContextKey int
-
 // WithHost associates the given host with the parent context and returns the resulting context.
 // It panics if the provided host is nil.
 func WithHost[RH any, EH any](parent context.Context, h *host.Host[RH, EH]) context.Context {
 	if h == nil {
 		panic("expected host to not be nil")
 	}
-	return context.WithValue(parent, &hostContextKey, h)
+	return withRouteUpdate(parent, func(rc *RouteContext) {
+		rc.Host = h
+	})
 }
 
 // Host retrieves the associated host from the given context.
 // It returns nil if the context is nil or if no host is associated with it.
 func Host[RH any, EH any](ctx context.Context) *host.Host[RH, EH] {
-	if ctx == nil {
-		return nil
-	}
-
-	h := ctx.Value(&hostContextKey)
+	rc := Route(ctx)
 
-	if h != nil {
-		return h.(*host.Host[RH, EH])
+	if rc != nil {
+		if h, ok := rc.Host.(*host.Host[RH, EH]); ok {
+			return h
+		}
 	}
 
 	return nil
@@ -43,20 +40,20 @@ func WithResource[RH any](parent context.Context, r *resource.Resource[RH]) cont
 	if r == nil {
 		panic("expected resource to not be nil")
 	}
-	return context.WithValue(parent, &resourceContextKey, r)
+	return withRouteUpdate(parent, func(rc *RouteContext) {
+		rc.Resource = r
+	})
 }
 
 // Resource retrieves the associated resource from the given context.
 // It returns nil if the context is nil or if no resource is associated with it.
 func Resource[RH any](ctx context.Context) *resource.Resource[RH] {
-	if ctx == nil {
-		return nil
-	}
-
-	h := ctx.Value(&resourceContextKey)
+	rc := Route(ctx)
 
-	if h != nil {
-		return h.(*resource.Resource[RH])
+	if rc != nil {
+		if r, ok := rc.Resource.(*resource.Resource[RH]); ok {
+			return r
+		}
 	}
 
 	return nil
@@ -2,43 +2,122 @@ package muxcontext
 
 import (
 	"context"
+	"fmt"
 )
 
-var pathParamsContextKey int
-var hostParamsContextKey int
+// Params is a read-only list of route parameters.
+//
+// Params are backed by slices of names and values so they can be collected while routing without allocating.
+// Use Get to look up a single parameter or Map for a map view of all of them.
+type Params struct {
+	names  []string
+	values []string
+	m      map[string]string
+}
 
-// params is a helper function that retrieves a map of string pairs from the given context using the provided key.
-// It returns nil if the context is nil or if no map is associated with the key.
-func params(ctx context.Context, key *int) map[string]string {
-	if ctx == nil {
-		return nil
+// NewParams creates parameters from matching name and value slices. The slices are not copied.
+// It panics if there's a mismatch between the number of names and values.
+func NewParams(names, values []string) Params {
+	if len(names) != len(values) {
+		panic(fmt.Errorf("mismatched parameter length: configured with %d name(s) got %d value(s)", len(names), len(values)))
 	}
 
-	h := ctx.Value(key)
+	return Params{
+		names:  names,
+		values: values,
+	}
+}
 
-	if h != nil {
-		return h.(map[string]string)
+// MapParams creates parameters from a map. The map is not copied.
+func MapParams(m map[string]string) Params {
+	return Params{
+		m: m,
 	}
+}
 
-	return nil
+// Len returns the number of parameters.
+func (p *Params) Len() int {
+	if p.names == nil {
+		return len(p.m)
+	}
+	return len(p.names)
+}
+
+// Get returns the value of the named parameter and whether it exists.
+// It does not allocate.
+func (p *Params) Get(name string) (string, bool) {
+	for i, paramName := range p.names {
+		if paramName == name {
+			return p.values[i], true
+		}
+	}
+
+	value, ok := p.m[name]
+	return value, ok
+}
+
+// Map returns a map view of the parameters or nil if there are none.
+// A new map is built on each call for parameters collected while routing so the route context, which is shared by
+// the request, is never modified and handlers may call it from several goroutines. Parameters created with
+// MapParams return their map, which must not be modified.
+func (p *Params) Map() map[string]string {
+	if p.m != nil || len(p.names) == 0 {
+		return p.m
+	}
+
+	m := make(map[string]string, len(p.names))
+	for i, paramName := range p.names {
+		m[paramName] = p.values[i]
+	}
+	return m
 }
 
 // WithPathParams associates the given path parameters with the parent context and returns the resulting context.
 func WithPathParams(parent context.Context, params map[string]string) context.Context {
-	return context.WithValue(parent, &pathParamsContextKey, params)
+	return withRouteUpdate(parent, func(rc *RouteContext) {
+		rc.PathParams = MapParams(params)
+	})
 }
 
 // PathParams retrieves the associated path parameters from the given context.
 func PathParams(ctx context.Context) map[string]string {
-	return params(ctx, &pathParamsContextKey)
+	if rc := Route(ctx); rc != nil {
+		return rc.PathParams.Map()
+	}
+	return nil
+}
+
+// PathParam retrieves a single path parameter from the given context.
+// It returns an empty string if the parameter does not exist. Unlike PathParams it does not allocate.
+func PathParam(ctx context.Context, name string) string {
+	if rc := Route(ctx); rc != nil {
+		value, _ := rc.PathParams.Get(name)
+		return value
+	}
+	return ""
 }
 
 // WithHostParams associates the given host parameters with the parent context and returns the resulting context.
 func WithHostParams(parent context.Context, params map[string]string) context.Context {
-	return context.WithValue(parent, &hostParamsContextKey, params)
+	return withRouteUpdate(parent, func(rc *RouteContext) {
+		rc.HostParams = MapParams(params)
+	})
 }
 
 // HostParams retrieves the associated host parameters from the given context.
 func HostParams(ctx context.Context) map[string]string {
-	return params(ctx, &hostParamsContextKey)
+	if rc := Route(ctx); rc != nil {
+		return rc.HostParams.Map()
+	}
+	return nil
+}
+
+// HostParam retrieves a single host parameter from the given context.
+// It returns an empty string if the parameter does not exist. Unlike HostParams it does not allocate.
+func HostParam(ctx context.Context, name string) string {
+	if rc := Route(ctx); rc != nil {
+		value, _ := rc.HostParams.Get(name)
+		return value
+	}
+	return ""
 }
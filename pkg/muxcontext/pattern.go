@@ -0,0 +1,28 @@
+package muxcontext
+
+import (
+	"context"
+)
+
+// patterned is implemented by hosts and resources regardless of their handler types.
+type patterned interface {
+	Pattern() string
+}
+
+// HostPattern retrieves the pattern of the host that matched the request, e.g. "{db}.example.com".
+// It returns an empty string if no host is associated with the context or the request matched the default host.
+func HostPattern(ctx context.Context) string {
+	if rc := Route(ctx); rc != nil {
+		return rc.HostPattern()
+	}
+	return ""
+}
+
+// PathPattern retrieves the pattern of the resource that matched the request, e.g. "/docs/{id}".
+// It returns an empty string if no resource is associated with the context.
+func PathPattern(ctx context.Context) string {
+	if rc := Route(ctx); rc != nil {
+		return rc.PathPattern()
+	}
+	return ""
+}
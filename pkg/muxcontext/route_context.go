@@ -0,0 +1,199 @@
+package muxcontext
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+var routeContextKey int
+
+// RouteContext holds all of the routing information for a request.
+//
+// The mux stores a single RouteContext in the request context instead of one context value per field.
+// The accessor functions in this package, such as Host and PathParams, read from it.
+type RouteContext struct {
+	Host       any          // Host is the *host.Host that matched the request. Use the Host function to retrieve it typed.
+	Resource   any          // Resource is the *resource.Resource that matched the request. Use the Resource function to retrieve it typed.
+	PathParams Params       // PathParams are the parameters parsed from the URL path.
+	HostParams Params       // HostParams are the parameters parsed from the hostname.
+	Logger     *slog.Logger // Logger is the logger for the request, if the host or mux has one.
+	Locale     language.Tag // Locale is the language from the path prefix on hosts with locales, language.Und otherwise.
+	Variant    string       // Variant is the name of the split variant that serves the request, empty if the method has no split.
+
+	// Forwarded holds the original client request values if the request was forwarded by a trusted proxy.
+	Forwarded ForwardedRequest
+
+	pathValues [8]string
+	hostValues [4]string
+	parent     context.Context
+}
+
+// Reset clears all of the fields so the route context can be reused.
+func (rc *RouteContext) Reset() {
+	*rc = RouteContext{}
+}
+
+// PathValues returns an empty slice backed by storage inside the route context.
+// It can be used to collect path parameter values without allocating for most routes.
+func (rc *RouteContext) PathValues() []string {
+	return rc.pathValues[:0]
+}
+
+// HostValues returns an empty slice backed by storage inside the route context.
+// It can be used to collect host parameter values without allocating for most hosts.
+func (rc *RouteContext) HostValues() []string {
+	return rc.hostValues[:0]
+}
+
+// HostPattern returns the pattern of the host that matched the request or an empty string if there is none.
+func (rc *RouteContext) HostPattern() string {
+	if p, ok := rc.Host.(patterned); ok {
+		return p.Pattern()
+	}
+	return ""
+}
+
+// PathPattern returns the pattern of the resource that matched the request or an empty string if there is none.
+func (rc *RouteContext) PathPattern() string {
+	if p, ok := rc.Resource.(patterned); ok {
+		return p.Pattern()
+	}
+	return ""
+}
+
+var routeContextPool = sync.Pool{
+	New: func() any {
+		return new(RouteContext)
+	},
+}
+
+// AcquireRouteContext returns an empty route context from a shared pool.
+//
+// Route contexts acquired from the pool must be returned with ReleaseRouteContext once the request is complete and
+// must not be referenced afterwards. Use WithPooledRouteContext to associate them with a context.
+func AcquireRouteContext() *RouteContext {
+	return routeContextPool.Get().(*RouteContext)
+}
+
+// ReleaseRouteContext resets the route context and returns it to the shared pool.
+func ReleaseRouteContext(rc *RouteContext) {
+	rc.Reset()
+	routeContextPool.Put(rc)
+}
+
+// pooledRouteContext is the context returned by WithPooledRouteContext. It references a pooled route context
+// without being part of it, so it stays valid after the route context is released.
+type pooledRouteContext struct {
+	context.Context
+	rc atomic.Pointer[RouteContext]
+}
+
+// Value returns the route context for the route context key, or nil once it was released, and the values of the
+// parent context otherwise.
+func (c *pooledRouteContext) Value(key any) any {
+	if key == &routeContextKey {
+		if rc := c.rc.Load(); rc != nil {
+			return rc
+		}
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
+// WithPooledRouteContext associates a route context from AcquireRouteContext with the parent context and returns
+// the resulting context. Release the route context with ReleasePooledRouteContext instead of ReleaseRouteContext.
+//
+// Unlike WithRouteContext the parent is not stored in the route context. The returned context, and any context
+// derived from it, can be used after the route context is released, for example by a goroutine that outlives the
+// request, but no longer has a route context.
+func WithPooledRouteContext(parent context.Context, rc *RouteContext) context.Context {
+	c := &pooledRouteContext{Context: parent}
+	c.rc.Store(rc)
+	return c
+}
+
+// ReleasePooledRouteContext detaches the route context from a context returned by WithPooledRouteContext and
+// returns it to the shared pool.
+func ReleasePooledRouteContext(ctx context.Context) {
+	c := ctx.(*pooledRouteContext)
+	if rc := c.rc.Swap(nil); rc != nil {
+		ReleaseRouteContext(rc)
+	}
+}
+
+// routeValueContext is the context returned by WithRouteContext. It is the route context itself so associating a
+// route context with a context does not allocate a separate context value.
+type routeValueContext RouteContext
+
+// Deadline returns the deadline of the parent context.
+func (c *routeValueContext) Deadline() (time.Time, bool) {
+	return c.parent.Deadline()
+}
+
+// Done returns the done channel of the parent context.
+func (c *routeValueContext) Done() <-chan struct{} {
+	return c.parent.Done()
+}
+
+// Err returns the error of the parent context.
+func (c *routeValueContext) Err() error {
+	return c.parent.Err()
+}
+
+// Value returns the route context for the route context key and the values of the parent context otherwise.
+func (c *routeValueContext) Value(key any) any {
+	if key == &routeContextKey {
+		return (*RouteContext)(c)
+	}
+	return c.parent.Value(key)
+}
+
+// WithRouteContext associates the given route context with the parent context and returns the resulting context.
+// The route context is stored by reference so changes made to it after this call are visible through the context.
+//
+// The returned context is backed by the route context so this does not allocate. A route context must only be
+// associated with one parent context.
+func WithRouteContext(parent context.Context, rc *RouteContext) context.Context {
+	rc.parent = parent
+	return (*routeValueContext)(rc)
+}
+
+// Route retrieves the route context from the given context.
+// It returns nil if the context is nil or if no route context is associated with it.
+func Route(ctx context.Context) *RouteContext {
+	if ctx == nil {
+		return nil
+	}
+
+	switch c := ctx.(type) {
+	case *routeValueContext:
+		return (*RouteContext)(c)
+	case *pooledRouteContext:
+		return c.rc.Load()
+	}
+
+	rc, _ := ctx.Value(&routeContextKey).(*RouteContext)
+	return rc
+}
+
+// withRouteUpdate returns a new context with a copy of the route context from the parent, modified by the update
+// function. The route context of the parent is never modified.
+//
+// Parameter values collected while routing are backed by storage inside the route context of the parent, which is
+// reused once a pooled route context is released, so they are copied.
+func withRouteUpdate(parent context.Context, update func(rc *RouteContext)) context.Context {
+	rc := new(RouteContext)
+	if existing := Route(parent); existing != nil {
+		*rc = *existing
+		rc.PathParams.values = slices.Clone(existing.PathParams.values)
+		rc.HostParams.values = slices.Clone(existing.HostParams.values)
+	}
+	update(rc)
+	return WithRouteContext(parent, rc)
+}
@@ -0,0 +1,22 @@
+package muxcontext
+
+import (
+	"context"
+)
+
+// WithVariant associates the given split variant name with the parent context and returns the resulting context.
+func WithVariant(parent context.Context, variant string) context.Context {
+	return withRouteUpdate(parent, func(rc *RouteContext) {
+		rc.Variant = variant
+	})
+}
+
+// Variant retrieves the name of the split variant that serves the request from the given context, e.g. to label
+// metrics by variant.
+// It returns an empty string if the context is nil or if the method of the request has no split.
+func Variant(ctx context.Context) string {
+	if rc := Route(ctx); rc != nil {
+		return rc.Variant
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+// Package predicate provides conditions on request headers, query parameters, cookies and host parameters that select
+// between handlers registered for the same path and method.
+package predicate
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// Request is the view of a request that predicates are evaluated against. It keeps predicates independent of the
+// handler types of the mux, use HTTPRequest to adapt an *http.Request.
+type Request interface {
+	Header(name string) string            // Header returns the first value of the header or an empty string if it is not set.
+	Query(name string) (string, bool)     // Query returns the first value of the query parameter and whether it is present.
+	Cookie(name string) (string, bool)    // Cookie returns the value of the cookie and whether it is present.
+	HostParam(name string) (string, bool) // HostParam returns the value of the parameter of the host pattern and whether it is present.
+}
+
+// Predicate decides whether a conditional handler serves a request.
+type Predicate interface {
+	Match(r Request) bool // Match returns true if the request satisfies the predicate.
+}
+
+// Func is an adapter to allow the use of ordinary functions as predicates.
+type Func func(r Request) bool
+
+// Match calls f(r).
+func (f Func) Match(r Request) bool {
+	return f(r)
+}
+
+// Header returns a predicate that matches requests where the first value of the header equals value.
+func Header(name, value string) Predicate {
+	return Func(func(r Request) bool {
+		return r.Header(name) == value
+	})
+}
+
+// HeaderRegexp returns a predicate that matches requests where the first value of the header matches the regular
+// expression. Requests without the header are matched against an empty string.
+func HeaderRegexp(name string, re *regexp.Regexp) Predicate {
+	return Func(func(r Request) bool {
+		return re.MatchString(r.Header(name))
+	})
+}
+
+// Query returns a predicate that matches requests with the query parameter, with or without a value.
+func Query(name string) Predicate {
+	return Func(func(r Request) bool {
+		_, ok := r.Query(name)
+		return ok
+	})
+}
+
+// QueryValue returns a predicate that matches requests where the first value of the query parameter equals value,
+// e.g. QueryValue("format", "csv") for ?format=csv.
+func QueryValue(name, value string) Predicate {
+	return Func(func(r Request) bool {
+		v, ok := r.Query(name)
+		return ok && v == value
+	})
+}
+
+// Cookie returns a predicate that matches requests with the cookie.
+func Cookie(name string) Predicate {
+	return Func(func(r Request) bool {
+		_, ok := r.Cookie(name)
+		return ok
+	})
+}
+
+// CookieValue returns a predicate that matches requests where the value of the cookie equals value.
+func CookieValue(name, value string) Predicate {
+	return Func(func(r Request) bool {
+		v, ok := r.Cookie(name)
+		return ok && v == value
+	})
+}
+
+// HostParam returns a predicate that matches requests where the parameter of the host pattern equals value, e.g.
+// HostParam("tenant", "acme") for acme.example.com on the host {tenant}.example.com.
+func HostParam(name, value string) Predicate {
+	return Func(func(r Request) bool {
+		v, ok := r.HostParam(name)
+		return ok && v == value
+	})
+}
+
+// All returns a predicate that matches requests that satisfy all of the predicates.
+func All(predicates ...Predicate) Predicate {
+	return Func(func(r Request) bool {
+		for _, p := range predicates {
+			if !p.Match(r) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// httpRequest adapts an *http.Request to the Request interface.
+type httpRequest struct {
+	r *http.Request
+}
+
+// HTTPRequest returns the Request view of an *http.Request. It has no host parameters since they are only known to
+// the mux.
+func HTTPRequest(r *http.Request) Request {
+	return httpRequest{r: r}
+}
+
+// Header returns the first value of the header.
+func (r httpRequest) Header(name string) string {
+	return r.r.Header.Get(name)
+}
+
+// Query returns the first value of the query parameter and whether it is present.
+func (r httpRequest) Query(name string) (string, bool) {
+	values, ok := r.r.URL.Query()[name]
+	if !ok {
+		return "", false
+	}
+	return values[0], true
+}
+
+// Cookie returns the value of the cookie and whether it is present.
+func (r httpRequest) Cookie(name string) (string, bool) {
+	cookie, err := r.r.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// HostParam returns false since an *http.Request has no host parameters.
+func (r httpRequest) HostParam(name string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,45 @@
+package predicate_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"proto.zip/studio/mux/pkg/predicate"
+)
+
+func TestPredicates(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/report?format=csv&download", nil)
+	r.Header.Set("Accept-Version", "2.1")
+	r.AddCookie(&http.Cookie{Name: "beta", Value: "on"})
+
+	req := predicate.HTTPRequest(r)
+
+	tests := []struct {
+		name      string
+		predicate predicate.Predicate
+		expected  bool
+	}{
+		{"header", predicate.Header("Accept-Version", "2.1"), true},
+		{"header mismatch", predicate.Header("Accept-Version", "2"), false},
+		{"header regexp", predicate.HeaderRegexp("Accept-Version", regexp.MustCompile(`^2(\.\d+)?$`)), true},
+		{"missing header regexp", predicate.HeaderRegexp("X-Missing", regexp.MustCompile(`.`)), false},
+		{"query", predicate.Query("download"), true},
+		{"missing query", predicate.Query("page"), false},
+		{"query value", predicate.QueryValue("format", "csv"), true},
+		{"query value mismatch", predicate.QueryValue("format", "json"), false},
+		{"cookie", predicate.Cookie("beta"), true},
+		{"missing cookie", predicate.Cookie("session"), false},
+		{"cookie value", predicate.CookieValue("beta", "on"), true},
+		{"cookie value mismatch", predicate.CookieValue("beta", "off"), false},
+		{"all", predicate.All(predicate.Query("download"), predicate.Cookie("beta")), true},
+		{"all mismatch", predicate.All(predicate.Query("download"), predicate.Cookie("session")), false},
+	}
+
+	for _, test := range tests {
+		if matched := test.predicate.Match(req); matched != test.expected {
+			t.Errorf("Expected %s predicate to return %t, got %t", test.name, test.expected, matched)
+		}
+	}
+}
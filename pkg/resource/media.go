@@ -0,0 +1,126 @@
+package resource
+
+import (
+	"errors"
+
+	"proto.zip/studio/mux/internal/negotiate"
+)
+
+// ErrUnsupportedMediaType is returned by MatchMethod when a method has media type variants but none of them
+// consume the Content-Type of the request.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ErrNotAcceptable is returned by MatchMethod when a method has media type variants but none of them produce a
+// media type allowed by the Accept header of the request.
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// MediaType selects a handler variant of a method by the media types of a request. Empty fields match any request.
+type MediaType struct {
+	Consumes string // Consumes is the media type of the request body matched against the Content-Type header, e.g. "application/json" or "multipart/*".
+	Produces string // Produces is the media type of the response negotiated with the Accept header, e.g. "text/html".
+}
+
+// mediaHandler is a request handler that serves requests with specific media types.
+type mediaHandler[H any] struct {
+	media    MediaType
+	consumes negotiate.MediaRange // consumes is the parsed Consumes media type.
+	produces negotiate.MediaRange // produces is the parsed Produces media type.
+	handler  H
+}
+
+// HandleMethodMedia associates a request handler with the given method name that serves requests with the media
+// types, see MatchMethod. A method may have many media type variants.
+// It panics if the media types are malformed or the method already has a variant for them.
+func (rh *Resource[H]) HandleMethodMedia(methodName string, media MediaType, handler H) {
+	variant := mediaHandler[H]{
+		media:   media,
+		handler: handler,
+	}
+
+	if media.Consumes != "" {
+		ranges := negotiate.ParseAccept(media.Consumes)
+		if len(ranges) != 1 {
+			panic(errors.New("malformed media type: " + media.Consumes))
+		}
+		variant.consumes = ranges[0]
+	}
+
+	if media.Produces != "" {
+		ranges := negotiate.ParseAccept(media.Produces)
+		if len(ranges) != 1 {
+			panic(errors.New("malformed media type: " + media.Produces))
+		}
+		variant.produces = ranges[0]
+	}
+
+	for _, existing := range rh.media[methodName] {
+		if existing.media == media {
+			panic(errors.New("can only be called once per method and media type"))
+		}
+	}
+
+	if rh.media == nil {
+		rh.media = make(map[string][]mediaHandler[H])
+	}
+	rh.media[methodName] = append(rh.media[methodName], variant)
+}
+
+// Negotiates returns true if the method has media type variants that are negotiated with the Accept header, in which
+// case responses vary by the Accept header.
+func (rh *Resource[H]) Negotiates(methodName string) bool {
+	for _, variant := range rh.media[methodName] {
+		if variant.media.Produces != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMedia selects the media type variant for a request with the Content-Type and Accept headers.
+//
+// Variants that consume the content type are negotiated by the q-values of the Accept header, ties are resolved in
+// favor of the variant added first. Variants without a Produces media type are used if no other variant is
+// acceptable. Variants without a Consumes media type serve requests with any content type, or none.
+//
+// The variants are evaluated in place and the headers are not parsed into media ranges, so matching doesn't allocate.
+func matchMedia[H any](variants []mediaHandler[H], contentType, accept string) (H, error) {
+	var zero H
+
+	requestType, hasType := negotiate.ParseMediaType(contentType)
+
+	var fallback *mediaHandler[H]
+	var best *mediaHandler[H]
+	bestQ := 0.0
+	consumed := false
+
+	for i := range variants {
+		variant := &variants[i]
+		if variant.media.Consumes != "" && (!hasType || !variant.consumes.Covers(requestType)) {
+			continue
+		}
+		consumed = true
+
+		if variant.media.Produces == "" {
+			if fallback == nil {
+				fallback = variant
+			}
+			continue
+		}
+
+		if q := negotiate.AcceptQuality(accept, variant.produces); q > bestQ {
+			best = variant
+			bestQ = q
+		}
+	}
+
+	switch {
+	case !consumed:
+		return zero, ErrUnsupportedMediaType
+	case best != nil:
+		return best.handler, nil
+	case fallback != nil:
+		return fallback.handler, nil
+	default:
+		return zero, ErrNotAcceptable
+	}
+}
@@ -5,26 +5,55 @@ package resource
 import (
 	"errors"
 	"fmt"
+	"slices"
 
+	"proto.zip/studio/mux/pkg/predicate"
 	"proto.zip/studio/mux/pkg/tokenizer"
 )
 
+// ErrNoHandler is returned by MatchMethod when no handler of a method serves a request.
+var ErrNoHandler = errors.New("no handler matches the request")
+
+// conditionalHandler is a request handler that only serves requests that satisfy its predicate.
+type conditionalHandler[H any] struct {
+	predicate predicate.Predicate
+	priority  int
+	handler   H
+}
+
 // Resource represents a web resource with associated request handlers and parameter mappings.
 // A resource may be associated with more than one request method and handler.
 // RequestHandlerType is a generic type representing the handler for a specific method.
 type Resource[RequestHandlerType any] struct {
-	methods  map[string]RequestHandlerType
-	paramMap map[string][]tokenizer.Token
+	methods     map[string]RequestHandlerType
+	conditional map[string][]conditionalHandler[RequestHandlerType]
+	media       map[string][]mediaHandler[RequestHandlerType]
+	splits      map[string]*Split[RequestHandlerType]
+	paramMap    map[string][]string
+	defaults    map[string][]string
+	pattern     string
 }
 
 // New creates and initializes a new Resource instance.
 func New[H any]() *Resource[H] {
 	return &Resource[H]{
 		methods:  make(map[string]H),
-		paramMap: make(map[string][]tokenizer.Token),
+		paramMap: make(map[string][]string),
 	}
 }
 
+// NewWithPattern creates and initializes a new Resource instance for the path pattern it was registered with.
+func NewWithPattern[H any](pattern string) *Resource[H] {
+	r := New[H]()
+	r.pattern = pattern
+	return r
+}
+
+// Pattern returns the path pattern the resource was first registered with.
+func (rh *Resource[H]) Pattern() string {
+	return rh.pattern
+}
+
 // Method retrieves the request handler associated with the given method name.
 // It returns the handler and a boolean indicating if the handler exists.
 func (rh *Resource[H]) Method(methodName string) (H, bool) {
@@ -32,22 +61,120 @@ func (rh *Resource[H]) Method(methodName string) (H, bool) {
 	return handler, existing
 }
 
-// Methods returns a list of all method names that have associated request handlers in the Resource.
+// Methods returns a list of all method names that have associated request handlers in the Resource, including
+// methods that only have conditional handlers, media type variants or a split.
 func (rh *Resource[H]) Methods() []string {
-	keys := make([]string, 0, len(rh.methods))
+	keys := make([]string, 0, len(rh.methods)+len(rh.conditional)+len(rh.media)+len(rh.splits))
 	for k := range rh.methods {
 		keys = append(keys, k)
 	}
+	for k := range rh.splits {
+		keys = append(keys, k)
+	}
+	for k := range rh.conditional {
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range rh.media {
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
 	return keys
 }
 
+// HasMethod returns true if the method has a request handler, a split, any conditional handlers or media type
+// variants.
+func (rh *Resource[H]) HasMethod(methodName string) bool {
+	if _, ok := rh.methods[methodName]; ok {
+		return true
+	}
+	if _, ok := rh.splits[methodName]; ok {
+		return true
+	}
+	return len(rh.conditional[methodName]) > 0 || len(rh.media[methodName]) > 0
+}
+
+// HandleMethodWhen associates a conditional request handler with the given method name. The handler only serves
+// requests that satisfy the predicate, see MatchMethod.
+//
+// Conditional handlers are evaluated from the highest priority to the lowest. Handlers with the same priority are
+// evaluated in the order they were added.
+func (rh *Resource[H]) HandleMethodWhen(methodName string, p predicate.Predicate, priority int, handler H) {
+	if p == nil {
+		panic(errors.New("expected predicate to not be nil"))
+	}
+
+	if rh.conditional == nil {
+		rh.conditional = make(map[string][]conditionalHandler[H])
+	}
+
+	handlers := rh.conditional[methodName]
+	idx := len(handlers)
+	for i, existing := range handlers {
+		if existing.priority < priority {
+			idx = i
+			break
+		}
+	}
+
+	rh.conditional[methodName] = slices.Insert(handlers, idx, conditionalHandler[H]{
+		predicate: p,
+		priority:  priority,
+		handler:   handler,
+	})
+}
+
+// MatchMethod returns the handler that serves a request with the given method name. The handlers are tried in the
+// following order:
+//   - The first conditional handler whose predicate the request satisfies.
+//   - The media type variant negotiated with the Content-Type and Accept headers of the request.
+//   - The variant of the split associated with HandleMethodSplit, whose name is also returned.
+//   - The handler associated with HandleMethod.
+//
+// If no handler serves the request ErrNoHandler is returned, unless the method has media type variants in which case
+// ErrUnsupportedMediaType or ErrNotAcceptable is returned.
+func (rh *Resource[H]) MatchMethod(methodName string, r predicate.Request) (H, string, error) {
+	for _, conditional := range rh.conditional[methodName] {
+		if conditional.predicate.Match(r) {
+			return conditional.handler, "", nil
+		}
+	}
+
+	var mediaErr error
+	if variants := rh.media[methodName]; len(variants) > 0 {
+		handler, err := matchMedia(variants, r.Header("Content-Type"), r.Header("Accept"))
+		if err == nil {
+			return handler, "", nil
+		}
+		mediaErr = err
+	}
+
+	if split, existing := rh.splits[methodName]; existing {
+		variant := split.choose(r)
+		return variant.Handler, variant.Name, nil
+	}
+
+	if handler, existing := rh.methods[methodName]; existing {
+		return handler, "", nil
+	}
+
+	var zero H
+	if mediaErr != nil {
+		return zero, "", mediaErr
+	}
+	return zero, "", ErrNoHandler
+}
+
 // HandleMethod associates a request handler with the given method name.
-// It panics if the method name already has an associated handler.
+// It panics if the method name already has an associated handler or split.
 func (rh *Resource[H]) HandleMethod(methodName string, handler H) {
 	nameStr := methodName
 	_, existing := rh.methods[nameStr]
+	_, split := rh.splits[nameStr]
 
-	if existing {
+	if existing || split {
 		panic(errors.New("can only be called once per method"))
 	}
 
@@ -64,10 +191,45 @@ func (rh *Resource[H]) SetParamNames(methodName string, paramNames []tokenizer.T
 		panic(errors.New("can only be called once per method"))
 	}
 
-	rh.paramMap[nameStr] = paramNames
+	names := make([]string, len(paramNames))
+	for i, paramName := range paramNames {
+		names[i] = string(paramName)
+	}
+
+	rh.paramMap[nameStr] = names
+}
+
+// ParamNames returns the parameter names for a specific method in the order they appear in the path pattern.
+// Parameters with default values that are not part of the path come last, see SetParamDefaults.
+// It returns nil if the method has no parameters.
+func (rh *Resource[H]) ParamNames(methodName string) []string {
+	return rh.paramMap[methodName]
+}
+
+// SetParamDefaults sets the values of the last parameters of a method when they are not part of the path.
+// This is used for optional segments of a path pattern with default values, e.g. /reports/{year}/{month=01}
+// registers /reports/{year} with the default "01" for month.
+// It panics if defaults for the method have already been set.
+func (rh *Resource[H]) SetParamDefaults(methodName string, defaults []string) {
+	if _, existing := rh.defaults[methodName]; existing {
+		panic(errors.New("can only be called once per method"))
+	}
+
+	if rh.defaults == nil {
+		rh.defaults = make(map[string][]string)
+	}
+	rh.defaults[methodName] = defaults
+}
+
+// ParamDefaults returns the values of the last parameters of a method that are not part of the path.
+// They must be appended to the values matched in the path to get one value for each name returned by ParamNames.
+// It returns nil if the method has no default values.
+func (rh *Resource[H]) ParamDefaults(methodName string) []string {
+	return rh.defaults[methodName]
 }
 
 // ParamMap maps the provided parameter values to their respective names for a given method.
+// Default values are added if paramValues only contains the values matched in the path.
 // It panics if there's a mismatch between the number of configured parameter names and provided values.
 func (rh *Resource[H]) ParamMap(methodName string, paramValues []tokenizer.Token) map[string]string {
 	paramNames, ok := rh.paramMap[string(methodName)]
@@ -76,14 +238,23 @@ func (rh *Resource[H]) ParamMap(methodName string, paramValues []tokenizer.Token
 		return nil
 	}
 
-	if len(paramNames) != len(paramValues) {
+	defaults := rh.defaults[methodName]
+	if len(paramValues)+len(defaults) != len(paramNames) {
+		defaults = nil
+	}
+
+	if len(paramNames) != len(paramValues)+len(defaults) {
 		panic(fmt.Errorf("mismatched parameter length: configured with %d name(s) got %d value(s)", len(paramNames), len(paramValues)))
 	}
 
 	paramMap := make(map[string]string, len(paramNames))
 
-	for paramIdx, paramName := range paramNames {
-		paramMap[string(paramName)] = string(paramValues[paramIdx])
+	for paramIdx, paramValue := range paramValues {
+		paramMap[paramNames[paramIdx]] = string(paramValue)
+	}
+
+	for defaultIdx, defaultValue := range defaults {
+		paramMap[paramNames[len(paramValues)+defaultIdx]] = defaultValue
 	}
 
 	return paramMap
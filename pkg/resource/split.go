@@ -0,0 +1,221 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"proto.zip/studio/mux/pkg/predicate"
+)
+
+// Variant is one of the weighted handlers of a split, e.g. the stable and canary versions of an endpoint.
+type Variant[H any] struct {
+	Name    string // Name identifies the variant in SetWeights and is exposed to handlers, e.g. for metrics.
+	Weight  int    // Weight is the share of requests the variant serves relative to the other variants.
+	Handler H
+}
+
+// Sticky selects the request value that assigns requests to variants. Requests with the same value are served by the
+// same variant as long as the weights don't change. The first field that is set and present in the request is used,
+// requests without any of them are assigned at random. The zero value assigns all requests at random.
+type Sticky struct {
+	Cookie    string // Cookie is the name of a cookie, e.g. a session id.
+	Header    string // Header is the name of a header, e.g. "X-User-Id".
+	HostParam string // HostParam is the name of a parameter of the host pattern, e.g. "tenant".
+}
+
+// key returns the value of the request that assigns it to a variant and whether the request has one.
+func (s Sticky) key(r predicate.Request) (string, bool) {
+	if s.Cookie != "" {
+		if value, ok := r.Cookie(s.Cookie); ok && value != "" {
+			return value, true
+		}
+	}
+	if s.Header != "" {
+		if value := r.Header(s.Header); value != "" {
+			return value, true
+		}
+	}
+	if s.HostParam != "" {
+		if value, ok := r.HostParam(s.HostParam); ok && value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Split divides the requests of a method between weighted handler variants, for canary releases and A/B tests.
+// A split may be registered on any number of methods and resources, they all share its weights.
+//
+// Weights can be changed with SetWeights at any time, including while requests are being served. Lookups read an
+// immutable copy of the weights without locking.
+type Split[H any] struct {
+	sticky   Sticky
+	variants []Variant[H]
+	mu       sync.Mutex
+	weights  atomic.Pointer[[]int] // weights are the cumulative weights of the variants.
+}
+
+// NewSplit creates a split between the variants that assigns requests by the sticky value.
+// It panics if there are no variants, the names are empty or not unique, or the weights are not valid, see
+// SetWeights.
+func NewSplit[H any](sticky Sticky, variants ...Variant[H]) *Split[H] {
+	if len(variants) == 0 {
+		panic(errors.New("expected at least one variant"))
+	}
+
+	weights := make(map[string]int, len(variants))
+	for _, variant := range variants {
+		if variant.Name == "" {
+			panic(errors.New("expected variant name to not be empty"))
+		}
+		if _, existing := weights[variant.Name]; existing {
+			panic(fmt.Errorf("duplicate variant name '%s'", variant.Name))
+		}
+		weights[variant.Name] = variant.Weight
+	}
+
+	s := &Split[H]{
+		sticky:   sticky,
+		variants: slices.Clone(variants),
+	}
+
+	if err := s.SetWeights(weights); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Sticky returns the request value that assigns requests to variants.
+func (s *Split[H]) Sticky() Sticky {
+	return s.sticky
+}
+
+// Weights returns the current weight of each variant by name.
+func (s *Split[H]) Weights() map[string]int {
+	cumulative := *s.weights.Load()
+
+	weights := make(map[string]int, len(s.variants))
+	previous := 0
+	for i, variant := range s.variants {
+		weights[variant.Name] = cumulative[i] - previous
+		previous = cumulative[i]
+	}
+	return weights
+}
+
+// SetWeights changes the weights of the named variants, variants that are not in the map keep their weight. A
+// variant with a weight of zero serves no requests, e.g. to roll back a canary.
+//
+// It returns an error without changing any weight if a name is unknown, a weight is negative or all of the weights
+// would be zero.
+func (s *Split[H]) SetWeights(weights map[string]int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("negative weight %d for variant '%s'", weight, name)
+		}
+		if s.index(name) < 0 {
+			return fmt.Errorf("unknown variant '%s'", name)
+		}
+	}
+
+	var current []int
+	if existing := s.weights.Load(); existing != nil {
+		current = *existing
+	}
+
+	cumulative := make([]int, len(s.variants))
+	total, previous := 0, 0
+	for i, variant := range s.variants {
+		weight, ok := weights[variant.Name]
+		if !ok && current != nil {
+			weight = current[i] - previous
+		}
+		if current != nil {
+			previous = current[i]
+		}
+
+		total += weight
+		cumulative[i] = total
+	}
+
+	if total == 0 {
+		return errors.New("expected at least one variant to have a weight")
+	}
+
+	s.weights.Store(&cumulative)
+	return nil
+}
+
+// index returns the index of the named variant or -1 if there is none.
+func (s *Split[H]) index(name string) int {
+	for i, variant := range s.variants {
+		if variant.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// choose returns the variant that serves the request. Requests with a sticky value are assigned by a hash of it,
+// other requests at random.
+func (s *Split[H]) choose(r predicate.Request) *Variant[H] {
+	cumulative := *s.weights.Load()
+	total := cumulative[len(cumulative)-1]
+
+	var bucket int
+	if key, ok := s.sticky.key(r); ok {
+		bucket = int(hashKey(key) % uint64(total))
+	} else {
+		bucket = rand.Intn(total)
+	}
+
+	for i, limit := range cumulative {
+		if bucket < limit {
+			return &s.variants[i]
+		}
+	}
+	return &s.variants[len(s.variants)-1]
+}
+
+// hashKey returns the 64-bit FNV-1a hash of the key. It is computed inline since hash/fnv allocates.
+func hashKey(key string) uint64 {
+	hash := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= 1099511628211
+	}
+	return hash
+}
+
+// HandleMethodSplit associates a split between weighted handler variants with the given method name. The split takes
+// the place of the handler of HandleMethod, see MatchMethod.
+// It panics if the method name already has an associated handler or split.
+func (rh *Resource[H]) HandleMethodSplit(methodName string, split *Split[H]) {
+	if split == nil {
+		panic(errors.New("expected split to not be nil"))
+	}
+
+	if _, existing := rh.methods[methodName]; existing {
+		panic(errors.New("can only be called once per method"))
+	}
+	if _, existing := rh.splits[methodName]; existing {
+		panic(errors.New("can only be called once per method"))
+	}
+
+	if rh.splits == nil {
+		rh.splits = make(map[string]*Split[H])
+	}
+	rh.splits[methodName] = split
+}
+
+// Split returns the split associated with the given method name or nil if there is none.
+func (rh *Resource[H]) Split(methodName string) *Split[H] {
+	return rh.splits[methodName]
+}
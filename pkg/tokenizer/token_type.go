@@ -9,6 +9,7 @@ const (
 	TokenTypeLiteral                      // TokenTypeLiteral represents a literal token.
 	TokenTypeLabel                        // TokenTypeLabel represents a label token.
 	TokenTypeWildcard                     // TokenTypeWildcard represents a wildcard token.
+	TokenTypePattern                      // TokenTypePattern represents a token that mixes literals and labels, e.g. "{name}.{ext}".
 	TokenTypeUserDefined TokenType = 10   // TokenTypeUserDefined is the starting point for user-defined token types.
 )
 
@@ -24,6 +25,8 @@ func (tt TokenType) String() string {
 		return "LABEL"
 	case TokenTypeWildcard:
 		return "WILDCARD"
+	case TokenTypePattern:
+		return "PATTERN"
 	default:
 		if tt > TokenTypeUserDefined {
 			return "USER"
@@ -4,17 +4,32 @@ import "fmt"
 
 // TokenizerError represents an error encountered during tokenization.
 // It contains information about the unexpected character and its position.
+//
+// Pattern tokenizers also set the pattern being tokenized and a human readable reason for the error.
 type TokenizerError struct {
 	Character rune
 	Pos       int
+	Pattern   string // Pattern is the full pattern that failed to tokenize, if known.
+	Reason    string // Reason describes why the character was unexpected, if known.
 }
 
 // Error returns a string representation of the TokenizerError.
 // If the character is 0, it indicates an unexpected end of string.
 func (e *TokenizerError) Error() string {
+	var msg string
 	if e.Character == 0 {
-		return fmt.Sprintf("unexpected end of string at %d", e.Pos)
+		msg = fmt.Sprintf("unexpected end of string at %d", e.Pos)
 	} else {
-		return fmt.Sprintf("unexpected character '%c' at %d", e.Character, e.Pos)
+		msg = fmt.Sprintf("unexpected character '%c' at %d", e.Character, e.Pos)
 	}
+
+	if e.Pattern != "" {
+		msg += fmt.Sprintf(" in pattern %q", e.Pattern)
+	}
+
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+
+	return msg
 }